@@ -0,0 +1,162 @@
+// Command benchhash measures hash allocation under concurrent load. It
+// creates links through LinkService.CreateLink against the configured
+// database, reports throughput, latency and collisions, then removes the
+// links it created unless -keep is set.
+//
+//	go run ./cmd/benchhash -links 20000 -workers 64
+//	go run ./cmd/benchhash -length 3 -alphabet abcdef
+//	HASH_MODE=sequence go run ./cmd/benchhash
+//
+// BenchmarkCreateLink in internal/link runs the same allocation without a
+// database server, on the memory and SQLite stores (make bench).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/internal/link"
+	"UrlShortenerBackend/migrations"
+	"UrlShortenerBackend/pkg/db"
+	"UrlShortenerBackend/pkg/logger"
+	"UrlShortenerBackend/pkg/migrate"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	total := flag.Int("links", 10000, "number of links to create")
+	workers := flag.Int("workers", 32, "number of concurrent writers")
+	alphabet := flag.String("alphabet", "", "hash alphabet, defaults to the configured one")
+	length := flag.Int("length", 0, "initial hash length, defaults to the configured one")
+	keep := flag.Bool("keep", false, "keep the created links instead of deleting them")
+	flag.Parse()
+
+	cfg := configs.Init()
+	log := logger.NewLogger(cfg)
+
+	hashConfig := cfg.Links.Hash
+	if *alphabet != "" {
+		hashConfig.Alphabet = *alphabet
+	}
+	if *length > 0 {
+		hashConfig.Length = *length
+		hashConfig.MaxLength = max(hashConfig.MaxLength, *length)
+	}
+
+	hashes, err := link.NewHashGenerator(hashConfig.Alphabet, hashConfig.Length, hashConfig.MaxLength, hashConfig.GrowThreshold)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid hash configuration")
+	}
+
+	codes, err := link.NewSequenceCodec(hashConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid hash configuration")
+	}
+
+	var database *db.Db
+	if cfg.Db.Driver != db.DRIVER_MEMORY {
+		database = db.NewDb(cfg)
+		migrator, err := migrate.NewMigrator(database.DB, migrations.FS)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load migrations")
+		}
+		if err := migrator.Check(); err != nil {
+			log.Fatal().Err(err).Msg("Database schema is not up to date, run `make migrate` first")
+		}
+	}
+
+	linkStore := link.NewLinkStore(cfg.Db.Driver, database, click.NewClickStore(cfg.Db.Driver, database))
+	linkService := link.NewLinkService(&link.LinkServiceDeps{
+		LinkStore: linkStore,
+		Hashes:    hashes,
+		Codes:     codes,
+		Config:    cfg,
+		Logger:    log,
+	})
+
+	userId := "benchhash-" + uuid.NewString()
+	expiresAt := time.Now().UTC().Add(time.Hour)
+
+	var (
+		mutex     sync.Mutex
+		ids       = make([]uint, 0, *total)
+		latencies = make([]time.Duration, 0, *total)
+		failures  int
+		wg        sync.WaitGroup
+	)
+
+	jobs := make(chan struct{})
+	start := time.Now()
+	for range *workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				began := time.Now()
+				created, err := linkService.CreateLink(&link.Link{
+					Url:       "https://example.com/benchmark",
+					UserId:    userId,
+					ExpiresAt: &expiresAt,
+				})
+				elapsed := time.Since(began)
+
+				mutex.Lock()
+				if err != nil {
+					failures++
+				} else {
+					ids = append(ids, created.ID)
+					latencies = append(latencies, elapsed)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for range *total {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	wg.Wait()
+	duration := time.Since(start)
+
+	stats := hashes.Stats()
+	slices.Sort(latencies)
+
+	fmt.Printf("driver         %s\n", cfg.Db.Driver)
+	fmt.Printf("mode           %s\n", hashConfig.Mode)
+	fmt.Printf("workers        %d\n", *workers)
+	fmt.Printf("created        %d in %s (%.0f links/s)\n", len(ids), duration.Round(time.Millisecond), float64(len(ids))/duration.Seconds())
+	fmt.Printf("failed         %d\n", failures)
+	fmt.Printf("latency p50    %s\n", percentile(latencies, 0.50))
+	fmt.Printf("latency p99    %s\n", percentile(latencies, 0.99))
+	if codes == nil {
+		fmt.Printf("attempts       %d\n", stats.Attempts)
+		fmt.Printf("collisions     %d (%.2f%%)\n", stats.Collisions, 100*float64(stats.Collisions)/float64(max(stats.Attempts, 1)))
+		fmt.Printf("hash length    %d -> %d\n", hashConfig.Length, stats.Length)
+	}
+
+	if !*keep {
+		for _, id := range ids {
+			if err := linkStore.ForceDeleteLink(id); err != nil {
+				log.Error().Err(err).Uint("link_id", id).Msg("Failed to delete benchmark link")
+			}
+		}
+	}
+
+	if failures > 0 {
+		os.Exit(1)
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p)].Round(time.Microsecond)
+}
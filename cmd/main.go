@@ -3,6 +3,14 @@
 // @description API for shortening URLs and managing shortened links
 
 // @BasePath /
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued by POST /api/v1/keys. "Authorization: Bearer <key>" is accepted as well
+
+// @securityDefinitions.basic BasicAuth
+// @description Operator account configured by http_server.user and http_server.password
 package main
 
 import (
@@ -16,10 +24,19 @@ import (
 	configs "UrlShortenerBackend/config"
 
 	_ "UrlShortenerBackend/docs"
+	"UrlShortenerBackend/internal/admin"
+	"UrlShortenerBackend/internal/auth"
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/internal/health"
 	"UrlShortenerBackend/internal/link"
+	"UrlShortenerBackend/internal/urlpolicy"
+	"UrlShortenerBackend/migrations"
 	"UrlShortenerBackend/pkg/db"
 	"UrlShortenerBackend/pkg/logger"
+	"UrlShortenerBackend/pkg/metrics"
 	"UrlShortenerBackend/pkg/middleware"
+	"UrlShortenerBackend/pkg/migrate"
+	"UrlShortenerBackend/pkg/mux"
 	"UrlShortenerBackend/pkg/swagger"
 
 	"github.com/rs/zerolog"
@@ -30,48 +47,158 @@ func main() {
 	cfg := configs.Init()
 
 	// Setting up router
-	router := http.NewServeMux()
+	router := mux.NewRouter()
 
 	//Logger
 	log := logger.NewLogger(cfg)
 	log.Info().Msg("Application started")
 	log.Info().Msg("Environment: " + cfg.Env)
 
+	//Health checks
+	checker := health.NewChecker(health.DEFAULT_CHECK_TIMEOUT)
+
 	//Database
-	database := db.NewDb(cfg)
-	log.Info().Msg("Database connected")
+	var database *db.Db
+	if cfg.Db.Driver == db.DRIVER_MEMORY {
+		log.Warn().Msg("Using in-memory storage, all data is lost on shutdown")
+	} else {
+		database = db.NewDb(cfg)
+		log.Info().Str("driver", database.Dialector.Name()).Msg("Database connected")
 
-	// Run auto-migration
-	log.Info().Msg("Starting auto migration...")
-	log.Info().Msg("Running migration for Link model...")
-	err := database.AutoMigrate(&link.Link{})
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to run migrations")
+		if err := metrics.InstrumentGorm(database.DB); err != nil {
+			log.Fatal().Err(err).Msg("Failed to instrument database")
+		}
+
+		migrator := checkMigrations(database, log)
+
+		checker.AddReadinessCheck("database", func(ctx context.Context) error {
+			sqlDB, err := database.DB.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		})
+		checker.AddReadinessCheck("migrations", func(ctx context.Context) error {
+			return migrator.WithContext(ctx).Check()
+		})
 	}
-	log.Info().Msg("Auto migration completed successfully!")
 
 	// Repositories
-	linkRepository := link.NewLinkRepository(database)
+	clickStore := click.NewClickStore(cfg.Db.Driver, database)
+	linkStore := link.NewLinkStore(cfg.Db.Driver, database, clickStore)
+	apiKeyStore := auth.NewApiKeyStore(cfg.Db.Driver, database)
 
 	//Services
-	linkService := link.NewLinkService(linkRepository, log)
+	hashes, err := link.NewHashGenerator(
+		cfg.Links.Hash.Alphabet,
+		cfg.Links.Hash.Length,
+		cfg.Links.Hash.MaxLength,
+		cfg.Links.Hash.GrowThreshold,
+	)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid hash configuration")
+	}
+
+	codes, err := link.NewSequenceCodec(cfg.Links.Hash)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid hash configuration")
+	}
+
+	policy, err := link.NewHashPolicy(cfg.Links.Vanity)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid vanity hash configuration")
+	}
+
+	urlPolicy, err := urlpolicy.New(cfg.Links.UrlPolicy, cfg.HTTPServer.Address)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid URL policy configuration")
+	}
+	go reloadOnHangup(urlPolicy.Blocklist, log)
+
+	clientIps, err := click.NewClientIpResolver(cfg.HTTPServer.TrustedProxies)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid trusted proxy configuration")
+	}
+
+	passwords, err := link.NewPasswordGate(cfg.Links.Password)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid link password configuration")
+	}
+	if cfg.Links.Password.CookieSecret == "" {
+		log.Warn().Msg("No link password cookie secret configured, unlocked links ask again after a restart")
+	}
+
+	linkService := link.NewLinkService(&link.LinkServiceDeps{
+		LinkStore: linkStore,
+		Hashes:    hashes,
+		Codes:     codes,
+		Policy:    policy,
+		UrlPolicy: urlPolicy,
+		Passwords: passwords,
+		Config:    cfg,
+		Logger:    log,
+	})
 	linkService.Start()
-	defer linkService.Stop()
+
+	checker.AddReadinessCheck("link_service", func(ctx context.Context) error {
+		return linkService.CheckAlive()
+	})
+
+	apiKeyService := auth.NewApiKeyService(apiKeyStore, log)
 
 	//Handlers
 	link.NewLinkHandler(router, &link.LinkHandlerDeps{
-		LinkRepository: linkRepository,
-		Config:         cfg,
-		Logger:         log,
+		LinkStore:   linkStore,
+		ClickStore:  clickStore,
+		LinkService: linkService,
+		ClientIps:   clientIps,
+		Config:      cfg,
+		Logger:      log,
+	})
+
+	auth.NewApiKeyHandler(router, &auth.ApiKeyHandlerDeps{
+		ApiKeyService: apiKeyService,
+		ClientIps:     clientIps,
+		Config:        cfg,
+		Logger:        log,
+	})
+
+	admin.NewAdminHandler(router, &admin.AdminHandlerDeps{
+		LinkStore:     linkStore,
+		LinkService:   linkService,
+		ApiKeyService: apiKeyService,
+		Config:        cfg,
+		Logger:        log,
+	})
+
+	health.NewHealthHandler(router, &health.HealthHandlerDeps{
+		Checker: checker,
+		Logger:  log,
 	})
 
 	// Swagger
 	swagger.SetupSwagger(router)
 
+	// Metrics
+	metrics.Registry.MustRegister(link.NewLinkCollector(
+		linkStore,
+		linkService.Cache,
+		cfg.Metrics.ExpiringSoonWindow,
+		log,
+	))
+	router.Handle("GET /metrics", metrics.Handler())
+
+	// Custom hashes must not shadow any route
+	policy.Reserve(router.Segments()...)
+
 	//Middlewares
 	stack := middleware.Chain(
+		middleware.Metrics(),
+		middleware.RequestId(),
 		middleware.Logging(log),
 		middleware.CORS(cfg.CORS.AllowedOrigins),
+		middleware.Auth(apiKeyService.Authenticate, "/api/"),
+		middleware.Route(),
 	)
 
 	server := &http.Server{
@@ -92,6 +219,13 @@ func main() {
 
 	log.Info().Msg("Shutting down application...")
 
+	// Failing readiness first so load balancers stop sending traffic
+	checker.StartShutdown()
+	if cfg.HTTPServer.DrainDelay > 0 {
+		log.Info().Dur("drain_delay", cfg.HTTPServer.DrainDelay).Msg("Draining traffic before shutdown")
+		time.Sleep(cfg.HTTPServer.DrainDelay)
+	}
+
 	// Completing remaining requests
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -101,13 +235,58 @@ func main() {
 		log.Error().Err(err).Msg("Server forced to shutdown")
 	}
 
+	// Stopping background jobs and flushing pending clicks
+	linkService.Stop()
+
 	// Closing database connection
-	sqlDB, _ := database.DB.DB()
-	sqlDB.Close()
+	if database != nil {
+		sqlDB, _ := database.DB.DB()
+		sqlDB.Close()
+	}
 
 	log.Info().Msg("Application stopped successfully")
 }
 
+// checkMigrations refuses to start against a database whose schema is
+// behind the migrations embedded in this binary.
+func checkMigrations(database *db.Db, log *zerolog.Logger) *migrate.Migrator {
+	migrator, err := migrate.NewMigrator(database.DB, migrations.FS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load migrations")
+	}
+
+	err = migrator.Check()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Database schema is not up to date, run `make migrate` first")
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to read schema version")
+	}
+	log.Info().Uint("version", version).Msg("Database schema is up to date")
+
+	return migrator
+}
+
+// reloadOnHangup reads the URL blocklist again on every SIGHUP.
+func reloadOnHangup(blocklist *urlpolicy.Blocklist, log *zerolog.Logger) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	for range hangup {
+		stats, err := blocklist.Reload()
+		if err != nil {
+			log.Error().Err(err).Str("file", stats.File).Msg("Failed to reload URL blocklist")
+			continue
+		}
+		log.Info().
+			Int("domains", stats.Domains).
+			Int("networks", stats.Networks).
+			Msg("URL blocklist reloaded")
+	}
+}
+
 func runServer(server *http.Server, log *zerolog.Logger) {
 	log.Info().Str("address", server.Addr).Msg("Starting HTTP server")
 	err := server.ListenAndServe()
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/migrations"
+	database "UrlShortenerBackend/pkg/db"
+	"UrlShortenerBackend/pkg/logger"
+	"UrlShortenerBackend/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+const usage = "usage: migrate up|down|status|goto N"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := configs.Init()
+
+	log := logger.NewLogger(cfg)
+
+	if cfg.Db.Driver == database.DRIVER_MEMORY {
+		log.Info().Msg("In-memory storage has no schema, nothing to migrate")
+		return
+	}
+
+	dialector, err := database.Dialector(cfg.Db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid database configuration")
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+
+	migrator, err := migrate.NewMigrator(db, migrations.FS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load migrations")
+	}
+
+	var count int
+	switch command := os.Args[1]; command {
+	case "up":
+		count, err = migrator.Up()
+	case "down":
+		count, err = migrator.Down()
+	case "goto":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		version, parseErr := strconv.ParseUint(os.Args[2], 10, 64)
+		if parseErr != nil {
+			log.Fatal().Str("version", os.Args[2]).Msg("Version must be a non-negative number")
+		}
+		count, err = migrator.Goto(uint(version))
+	case "status":
+		printStatus(migrator)
+		return
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	version, versionErr := migrator.Version()
+	if versionErr != nil && err == nil {
+		err = versionErr
+	}
+	if err != nil {
+		log.Fatal().Err(err).Int("migrations_run", count).Msg("Migration failed")
+	}
+
+	log.Info().
+		Str("driver", db.Dialector.Name()).
+		Int("migrations_run", count).
+		Uint("version", version).
+		Msg("Migration completed successfully")
+}
+
+func printStatus(migrator *migrate.Migrator) {
+	statuses, err := migrator.Status()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to read migration status:", err)
+		os.Exit(1)
+	}
+
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d  %-30s  %s\n", status.Version, status.Name, appliedAt)
+	}
+}
@@ -9,11 +9,16 @@ import (
 )
 
 type Config struct {
-	Env        string `yaml: "env" env:"ENV" env-default:"local" env-required:"true"`
+	Env        string `yaml:"env" env:"ENV" env-default:"local" env-required:"true"`
 	HTTPServer `yaml:"http_server"`
-	Logger     LogConfig  `yaml:"logger"`
-	Db         DbConfig   `yaml:"db"`
-	CORS       CORSConfig `yaml:"cors"`
+	Logger     LogConfig     `yaml:"logger"`
+	Db         DbConfig      `yaml:"db"`
+	CORS       CORSConfig    `yaml:"cors"`
+	Clicks     ClickConfig   `yaml:"clicks"`
+	Links      LinkConfig    `yaml:"links"`
+	Cache      CacheConfig   `yaml:"redirect_cache"`
+	Metrics    MetricsConfig `yaml:"metrics"`
+	ApiKeys    ApiKeyConfig  `yaml:"api_keys"`
 }
 
 type LogConfig struct {
@@ -21,20 +26,136 @@ type LogConfig struct {
 	Format string `yaml:"format" env-default:"console"`
 }
 
+// DbConfig selects the storage backend: "postgres" (default), "sqlite" with
+// a file path as DSN, or "memory" which keeps everything in process.
 type DbConfig struct {
-	Dsn string `yaml:"dsn" env-required:"true" env:"DB_DSN"`
+	Driver string `yaml:"driver" env:"DB_DRIVER" env-default:"postgres"`
+	Dsn    string `yaml:"dsn" env:"DB_DSN"`
 }
 
 type CORSConfig struct {
 	AllowedOrigins []string `yaml:"allowed_origins"`
 }
 
+type ClickConfig struct {
+	// IpHashSalt keys the HMAC of client addresses, changing it restarts
+	// unique visitor counts.
+	IpHashSalt    string        `yaml:"ip_hash_salt" env:"CLICKS_IP_HASH_SALT" env-required:"true"`
+	FlushInterval time.Duration `yaml:"flush_interval" env-default:"5s"`
+	BatchSize     int           `yaml:"batch_size" env-default:"500"`
+	BufferSize    int           `yaml:"buffer_size" env-default:"10000"`
+}
+
+type LinkConfig struct {
+	ExpiryCheckInterval time.Duration   `yaml:"expiry_check_interval" env-default:"1h"`
+	BatchMaxSize        int             `yaml:"batch_max_size" env:"LINKS_BATCH_MAX_SIZE" env-default:"500"`
+	ImportMaxRows       int             `yaml:"import_max_rows" env:"LINKS_IMPORT_MAX_ROWS" env-default:"1000"`
+	CountCacheSize      int             `yaml:"count_cache_size" env:"LINKS_COUNT_CACHE_SIZE" env-default:"1000"`
+	CountCacheTtl       time.Duration   `yaml:"count_cache_ttl" env:"LINKS_COUNT_CACHE_TTL" env-default:"1m"`
+	TrashRetention      time.Duration   `yaml:"trash_retention" env:"LINKS_TRASH_RETENTION" env-default:"720h"`
+	Hash                HashConfig      `yaml:"hash"`
+	Vanity              VanityConfig    `yaml:"vanity"`
+	UrlPolicy           UrlPolicyConfig `yaml:"url_policy"`
+	Password            PasswordConfig  `yaml:"password"`
+}
+
+// PasswordConfig applies to password-protected links. CookieSecret signs the
+// cookie set once a visitor gave the right password; without it a random
+// secret is drawn at startup, so unlocks end with the process and do not
+// carry over to other instances. A client gets MaxAttempts passwords per
+// link and AttemptWindow, and a link LinkMaxAttempts from all clients
+// together; 0 disables either limit.
+type PasswordConfig struct {
+	CookieSecret    string        `yaml:"cookie_secret" env:"LINK_PASSWORD_COOKIE_SECRET"`
+	CookieTtl       time.Duration `yaml:"cookie_ttl" env-default:"1h"`
+	MaxAttempts     int           `yaml:"max_attempts" env-default:"5"`
+	LinkMaxAttempts int           `yaml:"link_max_attempts" env-default:"100"`
+	AttemptWindow   time.Duration `yaml:"attempt_window" env-default:"15m"`
+	BcryptCost      int           `yaml:"bcrypt_cost" env-default:"10"`
+}
+
+// UrlPolicyConfig restricts destination URLs. Blocked domains match their
+// subdomains too. BlocklistFile holds more domains, IPs and CIDR ranges, one
+// per line, and is read again on SIGHUP or POST /admin/v1/url-policy/reload.
+// SelfHosts are the public host names of the shortener, links to them are
+// refused. ResolveHosts also refuses host names resolving into a blocked
+// network; it is checked when the link is saved, so a name repointed later
+// is not caught. FollowRedirects requests the destination to find redirect
+// loops.
+type UrlPolicyConfig struct {
+	AllowedSchemes  []string      `yaml:"allowed_schemes" env-default:"http,https"`
+	SelfHosts       []string      `yaml:"self_hosts" env:"URL_POLICY_SELF_HOSTS"`
+	BlockedDomains  []string      `yaml:"blocked_domains" env-default:"localhost"`
+	BlockedNetworks []string      `yaml:"blocked_networks" env-default:"0.0.0.0/8,10.0.0.0/8,100.64.0.0/10,127.0.0.0/8,169.254.0.0/16,172.16.0.0/12,192.168.0.0/16,::1/128,fc00::/7,fe80::/10"`
+	BlocklistFile   string        `yaml:"blocklist_file" env:"URL_POLICY_BLOCKLIST_FILE"`
+	ResolveHosts    bool          `yaml:"resolve_hosts" env:"URL_POLICY_RESOLVE_HOSTS" env-default:"true"`
+	FollowRedirects bool          `yaml:"follow_redirects" env-default:"false"`
+	MaxRedirects    int           `yaml:"max_redirects" env-default:"5"`
+	RedirectTimeout time.Duration `yaml:"redirect_timeout" env-default:"3s"`
+}
+
+// VanityConfig is the policy for hashes chosen by users. Charset is the
+// content of a regexp bracket expression. CaseFold is "preserve" to store
+// hashes as typed or "lower" to store and resolve them in lower case.
+// Reserved adds words to the first path segments of the registered routes,
+// ProfanityFile lists blocked words, one per line.
+type VanityConfig struct {
+	Charset       string   `yaml:"charset" env-default:"A-Za-z0-9_-"`
+	MinLength     int      `yaml:"min_length" env-default:"3"`
+	MaxLength     int      `yaml:"max_length" env-default:"32"`
+	CaseFold      string   `yaml:"case_fold" env-default:"preserve"`
+	Reserved      []string `yaml:"reserved" env-default:"swagger,static,assets"`
+	ProfanityFile string   `yaml:"profanity_file" env:"VANITY_PROFANITY_FILE"`
+}
+
+// HashConfig shapes generated hashes. In "random" mode hashes are Length
+// random characters, growing by one up to MaxLength whenever more than
+// GrowThreshold of the recent inserts collided. In "sequence" mode the hash
+// encodes the link ID with the salted alphabet and is at least MinLength
+// characters long.
+type HashConfig struct {
+	Mode          string  `yaml:"mode" env:"HASH_MODE" env-default:"random"`
+	Alphabet      string  `yaml:"alphabet" env:"HASH_ALPHABET" env-default:"1234567890abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"`
+	Length        int     `yaml:"length" env:"HASH_LENGTH" env-default:"10"`
+	MaxLength     int     `yaml:"max_length" env-default:"16"`
+	GrowThreshold float64 `yaml:"grow_threshold" env-default:"0.01"`
+	Salt          string  `yaml:"salt" env:"HASH_SALT"`
+	MinLength     int     `yaml:"min_length" env-default:"0"`
+}
+
+// CacheConfig sizes the in-process redirect cache. A size of 0 disables it.
+type CacheConfig struct {
+	Size        int           `yaml:"size" env:"REDIRECT_CACHE_SIZE" env-default:"10000"`
+	Ttl         time.Duration `yaml:"ttl" env-default:"5m"`
+	NegativeTtl time.Duration `yaml:"negative_ttl" env-default:"30s"`
+}
+
+// ApiKeyConfig limits how many keys a client address can create without
+// being authenticated, each of them for a new user. A limit of 0 disables
+// it.
+type ApiKeyConfig struct {
+	AnonymousLimit  int           `yaml:"anonymous_limit" env:"API_KEYS_ANONYMOUS_LIMIT" env-default:"5"`
+	AnonymousWindow time.Duration `yaml:"anonymous_window" env-default:"1h"`
+}
+
+// MetricsConfig tunes the gauges served on /metrics.
+type MetricsConfig struct {
+	ExpiringSoonWindow time.Duration `yaml:"expiring_soon_window" env-default:"24h"`
+}
+
 type HTTPServer struct {
 	Address     string        `yaml:"address" env-default:"localhost:8082"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 	User        string        `yaml:"user" env-required:"true"`
 	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// DrainDelay is how long /readyz fails before the server stops
+	// accepting requests, giving load balancers time to drain it.
+	DrainDelay time.Duration `yaml:"drain_delay" env:"HTTP_SERVER_DRAIN_DELAY" env-default:"5s"`
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies
+	// in front of the service. X-Forwarded-For is ignored unless the request
+	// comes from one of them.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"HTTP_SERVER_TRUSTED_PROXIES" env-separator:","`
 }
 
 func Init() *Config {
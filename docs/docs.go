@@ -15,358 +15,2786 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/links": {
+        "/admin/v1/cache": {
             "get": {
-                "description": "Get details of a specific shortened link by hash",
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Returns the size, hit, miss and eviction counters of the in-process redirect cache",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "links"
+                    "admin"
                 ],
-                "summary": "Get link details",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User ID of the link owner",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
+                "summary": "Get redirect cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "Cache statistics",
+                        "schema": {
+                            "$ref": "#/definitions/cache.Stats"
+                        }
                     },
+                    "401": {
+                        "description": "Invalid admin credentials",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "Hash of the shortened link",
-                        "name": "hash",
-                        "in": "query",
-                        "required": true
+                        "BasicAuth": []
                     }
                 ],
+                "description": "Drops every entry of the in-process redirect cache",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Purge the redirect cache",
                 "responses": {
                     "200": {
-                        "description": "Link details",
+                        "description": "Redirect cache purged",
                         "schema": {
-                            "$ref": "#/definitions/link.Link"
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Missing parameters",
+                    "401": {
+                        "description": "Invalid admin credentials",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/v1/jobs/lifetime": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Purges expired links right away instead of waiting for the next scheduled run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run the lifetime job",
+                "responses": {
+                    "200": {
+                        "description": "Number of purged links",
+                        "schema": {
+                            "$ref": "#/definitions/admin.LifetimeJobResponse"
                         }
                     },
-                    "403": {
-                        "description": "Link not found or user does not have access",
+                    "401": {
+                        "description": "Invalid admin credentials",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/v1/jobs/trash-purge": {
             "post": {
-                "description": "Creates a new shortened link",
-                "consumes": [
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Permanently removes the links deleted longer than the trash retention ago, instead of waiting for the next scheduled run",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run the trash purge job",
+                "responses": {
+                    "200": {
+                        "description": "Number of purged links",
+                        "schema": {
+                            "$ref": "#/definitions/admin.TrashPurgeJobResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin credentials",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/v1/links": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Lists links across all users, optionally filtered by owner and by a search over hash and destination URL",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "links"
+                    "admin"
                 ],
-                "summary": "Create a new shortened link",
+                "summary": "Search links of all users",
                 "parameters": [
                     {
-                        "description": "Data for creating a link",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/link.LinkCreateRequest"
-                        }
+                        "type": "string",
+                        "description": "Substring of the hash or destination URL",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner of the links",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exclude",
+                            "include",
+                            "only"
+                        ],
+                        "type": "string",
+                        "default": "exclude",
+                        "description": "Soft-deleted links",
+                        "name": "deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of items per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created link",
+                    "200": {
+                        "description": "List of links",
                         "schema": {
-                            "$ref": "#/definitions/link.Link"
+                            "$ref": "#/definitions/admin.GetAllLinksResponse"
                         }
                     },
                     "400": {
-                        "description": "Error in request parameters",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "User ID not found",
+                        "description": "Invalid parameters",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
-                    "409": {
-                        "description": "Hash already exists",
+                    "401": {
+                        "description": "Invalid admin credentials",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/v1/links/{id}": {
             "delete": {
-                "description": "Deletes a shortened link by hash",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
                 ],
+                "description": "Permanently deletes a link, whether or not it was soft-deleted, together with its click history",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "links"
+                    "admin"
                 ],
-                "summary": "Delete a shortened link",
+                "summary": "Permanently delete a link",
                 "parameters": [
                     {
-                        "description": "Data for deleting a link",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/link.LinkDeleteRequest"
-                        }
+                        "type": "integer",
+                        "description": "Link ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Link deleted successfully",
+                        "description": "Link permanently deleted",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Error in request parameters",
+                        "description": "Invalid link ID",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
-                    "403": {
-                        "description": "Link not found or user does not have permission",
+                    "401": {
+                        "description": "Invalid admin credentials",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "404": {
                         "description": "Link not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/links/add-days": {
+        "/admin/v1/links/{id}/restore": {
             "post": {
-                "description": "Increases the lifetime of all links belonging to a user by 1 day",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
                 ],
+                "description": "Restores a soft-deleted link unless its hash has been taken by another link since. A link restored after its expiry is purged again by the next lifetime job unless its expiry is extended",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "links"
+                    "admin"
                 ],
-                "summary": "Add days to all user links",
+                "summary": "Restore a deleted link",
                 "parameters": [
                     {
-                        "description": "User ID",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/link.AddDaysRequest"
-                        }
+                        "type": "integer",
+                        "description": "Link ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message with number of updated links",
+                        "description": "Restored link",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/link.Link"
                         }
                     },
                     "400": {
-                        "description": "Error in request parameters",
+                        "description": "Invalid link ID",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin credentials",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Deleted link not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Hash already exists",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/links/all": {
-            "get": {
-                "description": "Get a list of all links belonging to a user",
+        "/admin/v1/url-policy/reload": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Reads the blocklist file of the destination URL policy again. On error the previous blocklist stays in place",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "links"
-                ],
-                "summary": "Get all user links",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Number of items per page",
-                        "name": "limit",
-                        "in": "query"
-                    }
+                    "admin"
                 ],
+                "summary": "Reload the URL blocklist",
                 "responses": {
                     "200": {
-                        "description": "List of links",
+                        "description": "Loaded blocklist",
                         "schema": {
-                            "$ref": "#/definitions/link.GetAllLinksResponse"
+                            "$ref": "#/definitions/urlpolicy.BlocklistStats"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin credentials",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Blocklist could not be read",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
             }
         },
-        "/{hash}": {
-            "get": {
-                "description": "Redirects to the original URL using the provided hash",
+        "/admin/v1/users/{user_id}/keys": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Issues an API key for an existing user ID, e.g. for users whose links were created before API keys existed",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "text/html"
+                    "application/json"
                 ],
                 "tags": [
-                    "links"
+                    "admin"
                 ],
-                "summary": "Redirect to original URL",
+                "summary": "Issue an API key for a user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Hash of the shortened link",
-                        "name": "hash",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Key name",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/admin.IssueKeyRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "302": {
-                        "description": "Redirect to the original URL",
+                    "201": {
+                        "description": "Created key",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ApiKeyCreateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin credentials",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the API keys of the authenticated user, including revoked ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "List of keys",
+                        "schema": {
+                            "$ref": "#/definitions/auth.GetAllApiKeysResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Issues a new API key. Authenticated callers get an additional key for their own user, anonymous callers get a key for a newly created user, a limited number of times per client address. The key is only returned once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "description": "Key name",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ApiKeyCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created key",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ApiKeyCreateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many keys created anonymously from this address",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes one of the authenticated user's API keys",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key revoked successfully",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Hash parameter is missing",
-                        "schema": {
-                            "type": "string"
-                        }
+                    "400": {
+                        "description": "Invalid key ID",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get details of a specific shortened link by hash",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Get link details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hash of the shortened link",
+                        "name": "hash",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link details",
+                        "schema": {
+                            "$ref": "#/definitions/link.Link"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Link not found or user does not have access",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new shortened link",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Create a new shortened link",
+                "parameters": [
+                    {
+                        "description": "Data for creating a link",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created link",
+                        "schema": {
+                            "$ref": "#/definitions/link.Link"
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Hash already exists",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Moves a shortened link to the trash. It can be restored, and its hash stays reserved, until the trash retention has passed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Delete a shortened link",
+                "parameters": [
+                    {
+                        "description": "Data for deleting a link",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Link not found or user does not have permission",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/add-days": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Extends the expiry of one link, or of all links belonging to the authenticated user when no hash is given, by the given number of days and hours (1 day when both are omitted)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Extend link expiry",
+                "parameters": [
+                    {
+                        "description": "Optional hash and extension",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.AddDaysRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message with number of updated links",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Link not found or user does not have permission",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/all": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a list of the links belonging to the authenticated user, optionally filtered and sorted. Filters combine with AND, ranges include their start and exclude their end.\nPages are numbered by default. With the cursor parameter the listing is read with opaque cursors instead, empty for the first page, and the response is a GetLinksPageResponse: pages stay stable while links are created or deleted and deep pages cost no more than the first one. A cursor carries its order, sort and order may be left out with it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Get all user links",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Destination domain, subdomains included",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-sensitive prefix of the hash",
+                        "name": "hash_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag the link carries",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive text searched in the URL and the title",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum number of clicks",
+                        "name": "min_clicks",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after, RFC 3339 or YYYY-MM-DD",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before, RFC 3339 or YYYY-MM-DD",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expiring at or after, RFC 3339 or YYYY-MM-DD",
+                        "name": "expires_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expiring before, RFC 3339 or YYYY-MM-DD",
+                        "name": "expires_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "expires_at",
+                            "clicks"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort key, links without expiry come last",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort direction",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor mode, next_cursor or prev_cursor of a previous page, empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "exact",
+                            "estimate"
+                        ],
+                        "type": "string",
+                        "default": "none",
+                        "description": "Cursor mode only, whether to count the matching links, estimate serves a count cached for a short while",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of links, GetLinksPageResponse in cursor mode",
+                        "schema": {
+                            "$ref": "#/definitions/link.GetAllLinksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates every link of the array. In atomic mode (default) the links are inserted in one transaction and nothing is created when one fails. In partial mode each link is created on its own. The response has one result per link, in the order of the request",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Create shortened links in bulk",
+                "parameters": [
+                    {
+                        "enum": [
+                            "atomic",
+                            "partial"
+                        ],
+                        "type": "string",
+                        "default": "atomic",
+                        "description": "Failure handling",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Links to create",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/link.LinkCreateRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Every link was created",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBatchCreateResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Partial mode, some links failed",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBatchCreateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Atomic mode, a link is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBatchCreateResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Atomic mode, a hash is already in use",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBatchCreateResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many links or bytes in the batch",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/bulk/delete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the given hashes, or every link matching the filter, in one transaction and reports the outcome of each hash: deleted or not_found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Delete links in bulk",
+                "parameters": [
+                    {
+                        "description": "Hashes or filter selecting the links",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Every selected link was deleted",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Some hashes were not found",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many links selected",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/bulk/extend": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Extends the expiry of the given hashes, or of every link matching the filter, by the given days and hours (1 day when both are omitted) in one transaction, and reports the outcome of each hash: extended, no_expiry or not_found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Extend links expiry in bulk",
+                "parameters": [
+                    {
+                        "description": "Hashes or filter selecting the links, and extension",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkExtendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Every selected link was extended",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Some links could not be extended",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many links selected",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/bulk/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restores the given hashes, or every deleted link matching the filter, in one transaction and reports the outcome of each hash: restored, hash_taken when a live link uses the hash again, or not_found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Restore deleted links in bulk",
+                "parameters": [
+                    {
+                        "description": "Hashes or filter selecting the deleted links",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Every selected link was restored",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Some links could not be restored",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkBulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many links selected",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/clicks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the individual click events recorded for a link, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Get link click events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hash of the shortened link",
+                        "name": "hash",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Number of items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of click events",
+                        "schema": {
+                            "$ref": "#/definitions/link.GetClicksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Link not found or user does not have access",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Streams every live link of the authenticated user as CSV, a JSON array or newline-delimited JSON. The output can be sent back to the import endpoint",
+                "produces": [
+                    "text/csv",
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Export links",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "json",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Output format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Links of the user",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/link.LinkExportRow"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown format",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates links from CSV, a JSON array or newline-delimited JSON, as produced by the export endpoint. The format comes from the format parameter or the Content-Type. Every row is checked and created on its own like a single create, a custom hash already in use fails its row with 409. In sequence hash mode generated codes cannot be imported, those links get a new code and their row reports the original one. Rows still waiting when the import runs out of time fail with 503. The report holds the outcome of every row",
+                "consumes": [
+                    "text/csv",
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Import links",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "json",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Input format, defaults to the Content-Type, then csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Links to import, CSV needs a header with at least a url column",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/link.LinkExportRow"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Every row was imported",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkImportResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Some rows failed",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "The body cannot be read",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many rows or body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/trash": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the deleted links of the authenticated user, most recently deleted first. They can be restored until purge_at, after which they are removed for good",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "List deleted links",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted links",
+                        "schema": {
+                            "$ref": "#/definitions/link.GetTrashResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/{hash}": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes the destination URL, hash, expiry, title or tags of a link. Only fields present in the body are changed, a null expires_at removes the expiry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Update a shortened link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current hash of the shortened link",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated link",
+                        "schema": {
+                            "$ref": "#/definitions/link.Link"
+                        }
+                    },
+                    "400": {
+                        "description": "Error in request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Link not found or user does not have access",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Hash already exists",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/{hash}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Brings a link back from the trash. A link that had expired is deleted again by the next lifetime run unless its expiry is extended",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Restore a deleted link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hash of the deleted link",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored link",
+                        "schema": {
+                            "$ref": "#/definitions/link.Link"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No deleted link with this hash",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Another link holds the hash",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/{hash}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get clicks over time bucketed by hour, day or week, together with top referrers, top user-agent families and unique visitors",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Get link analytics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hash of the shortened link",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Bucket size",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Range start, RFC 3339 or YYYY-MM-DD (defaults to 7 days before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Range end, RFC 3339 or YYYY-MM-DD (defaults to now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of top referrers and user agents",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link analytics",
+                        "schema": {
+                            "$ref": "#/definitions/link.LinkStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Link not found or user does not have access",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Reports that the process is alive and serving requests",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "Process is alive",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "A liveness check failed",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Pings the database, verifies the schema is migrated and the link service loop is alive. Fails as soon as shutdown begins",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "Ready to serve traffic",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "A dependency is unavailable or the server is shutting down",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/{hash}": {
+            "get": {
+                "description": "Redirects to the original URL using the provided hash. Password-protected links answer with an HTML password form instead, unless the visitor unlocked the link recently",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Redirect to original URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hash of the shortened link",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password form of a protected link",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the original URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Hash parameter is missing",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "410": {
+                        "description": "Link has expired",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Checks the password submitted by the form of a protected link. The right password sets a short-lived cookie so later visits skip the form, and redirects to the original URL. Password attempts are limited per link and client address, and per link",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Unlock a password-protected link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hash of the shortened link",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password of the link",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "Redirect to the original URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Password form, the submission could not be read",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Password form, the password is wrong",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "410": {
+                        "description": "Link has expired",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Password form, too many password attempts",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "admin.GetAllLinksResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/link.Link"
+                    }
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_links": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "admin.IssueKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Migrated from user_id"
+                }
+            }
+        },
+        "admin.LifetimeJobResponse": {
+            "type": "object",
+            "properties": {
+                "deleted_links": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Lifetime update completed"
+                }
+            }
+        },
+        "admin.TrashPurgeJobResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Trash purge completed"
+                },
+                "purged_links": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "auth.ApiKey": {
+            "description": "API key model",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CI pipeline"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "usk_Ab3dE6gH"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "auth.ApiKeyCreateRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "CI pipeline"
+                }
+            }
+        },
+        "auth.ApiKeyCreateResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/auth.ApiKey"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "usk_Ab3dE6gHjK9mNpQrStUvWxYz0123456789abcdefg"
+                }
+            }
+        },
+        "auth.GetAllApiKeysResponse": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auth.ApiKey"
+                    }
+                }
+            }
+        },
+        "cache.Stats": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "example": 10000
+                },
+                "evictions": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "hits": {
+                    "type": "integer",
+                    "example": 98231
+                },
+                "misses": {
+                    "type": "integer",
+                    "example": 1742
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1250
+                }
+            }
+        },
+        "click.Bucket": {
+            "description": "Clicks aggregated over one time bucket",
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "start": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "unique_visitors": {
+                    "type": "integer",
+                    "example": 9
+                }
+            }
+        },
+        "click.ClickEvent": {
+            "description": "Click event model",
+            "type": "object",
+            "properties": {
+                "accept_language": {
+                    "type": "string",
+                    "example": "en-US,en;q=0.9"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "ip_hash": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "link_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "referrer": {
+                    "type": "string",
+                    "example": "https://google.com/"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0 (Windows NT 10.0; Win64; x64)"
+                },
+                "user_agent_family": {
+                    "type": "string",
+                    "example": "Chrome"
+                }
+            }
+        },
+        "click.CountEntry": {
+            "description": "Value with its number of clicks",
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "value": {
+                    "type": "string",
+                    "example": "https://google.com/"
+                }
+            }
+        },
+        "health.CheckResult": {
+            "description": "Outcome of a single health check",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "dial tcp 127.0.0.1:5432: connect: connection refused"
+                },
+                "latency_ms": {
+                    "type": "number",
+                    "example": 0.42
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "health.Report": {
+            "description": "Health probe report",
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "link.AddDaysRequest": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 7
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "hours": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 12
+                }
+            }
+        },
+        "link.BulkOutcome": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-08-22T00:00:00Z"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "deleted"
+                }
+            }
+        },
+        "link.GetAllLinksResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/link.Link"
+                    }
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_links": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "link.GetClicksResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/click.ClickEvent"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_events": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "link.GetTrashResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/link.TrashedLink"
+                    }
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_links": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "link.Link": {
+            "description": "Shortened link model",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-07-22T00:00:00Z"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "number_of_clicks": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "password_protected": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "marketing",
+                        "spring"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Spring campaign"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "link.LinkBatchCreateResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "atomic"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/link.LinkBatchItemResult"
+                    }
+                }
+            }
+        },
+        "link.LinkBatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/problem.Problem"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "link": {
+                    "$ref": "#/definitions/link.Link"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 201
+                }
+            }
+        },
+        "link.LinkBulkExtendRequest": {
+            "type": "object",
+            "required": [
+                "hashes"
+            ],
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 7
+                },
+                "filter": {
+                    "$ref": "#/definitions/link.LinkBulkFilter"
+                },
+                "hashes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "abc123",
+                        "def456"
+                    ]
+                },
+                "hours": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 12
+                }
+            }
+        },
+        "link.LinkBulkFilter": {
+            "type": "object",
+            "properties": {
+                "created_from": {
+                    "type": "string",
+                    "example": "2025-04-01T00:00:00Z"
+                },
+                "created_to": {
+                    "type": "string",
+                    "example": "2025-05-01T00:00:00Z"
+                },
+                "domain": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "example.com"
+                },
+                "tag": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "marketing"
+                }
+            }
+        },
+        "link.LinkBulkRequest": {
+            "type": "object",
+            "required": [
+                "hashes"
+            ],
+            "properties": {
+                "filter": {
+                    "$ref": "#/definitions/link.LinkBulkFilter"
+                },
+                "hashes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     },
-                    "404": {
-                        "description": "Link not found",
-                        "schema": {
-                            "type": "string"
-                        }
+                    "example": [
+                        "abc123",
+                        "def456"
+                    ]
+                }
+            }
+        },
+        "link.LinkBulkResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "delete"
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "matched": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/link.BulkOutcome"
                     }
+                },
+                "succeeded": {
+                    "type": "integer",
+                    "example": 2
                 }
             }
-        }
-    },
-    "definitions": {
-        "link.AddDaysRequest": {
+        },
+        "link.LinkCreateRequest": {
             "type": "object",
             "required": [
-                "user_id"
+                "url"
             ],
             "properties": {
-                "user_id": {
+                "expires_at": {
                     "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                    "example": "2025-07-22T00:00:00Z"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "custom123"
+                },
+                "password": {
+                    "description": "Password asked before redirecting, none when empty",
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 4,
+                    "example": "s3cret"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "marketing",
+                        "spring"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Spring campaign"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com"
                 }
             }
         },
-        "link.GetAllLinksResponse": {
+        "link.LinkDeleteRequest": {
             "type": "object",
+            "required": [
+                "hash"
+            ],
             "properties": {
-                "limit": {
+                "hash": {
+                    "type": "string",
+                    "example": "abc123"
+                }
+            }
+        },
+        "link.LinkExportRow": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-07-22T00:00:00Z"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "number_of_clicks": {
                     "type": "integer",
-                    "example": 10
+                    "example": 42
                 },
-                "links": {
+                "tags": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/link.Link"
+                        "type": "string"
+                    },
+                    "example": [
+                        "marketing",
+                        "spring"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Spring campaign"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com"
+                }
+            }
+        },
+        "link.LinkImportResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "format": {
+                    "type": "string",
+                    "example": "csv"
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/link.LinkImportRowResult"
                     }
+                }
+            }
+        },
+        "link.LinkImportRowResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/problem.Problem"
                 },
-                "page": {
+                "hash": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "original_hash": {
+                    "type": "string",
+                    "example": "x7Kp2Qa"
+                },
+                "row": {
                     "type": "integer",
                     "example": 1
                 },
-                "total_links": {
+                "status": {
                     "type": "integer",
-                    "example": 42
+                    "example": 201
+                }
+            }
+        },
+        "link.LinkStatsResponse": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/click.Bucket"
+                    }
                 },
-                "total_pages": {
+                "from": {
+                    "type": "string",
+                    "example": "2025-04-16T00:00:00Z"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "day"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-04-23T00:00:00Z"
+                },
+                "top_referrers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/click.CountEntry"
+                    }
+                },
+                "top_user_agents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/click.CountEntry"
+                    }
+                },
+                "total_clicks": {
                     "type": "integer",
-                    "example": 5
+                    "example": 120
+                },
+                "unique_visitors": {
+                    "type": "integer",
+                    "example": 87
                 }
             }
         },
-        "link.Link": {
-            "description": "Shortened link model",
+        "link.LinkUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "x-nullable": true,
+                    "example": "2025-08-22T00:00:00Z"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "renamed123"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "example": "n3w-s3cret"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "marketing",
+                        "summer"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Summer campaign"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.org"
+                }
+            }
+        },
+        "link.TrashedLink": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -377,6 +2805,10 @@ const docTemplate = `{
                     "type": "string",
                     "format": "date-time"
                 },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-07-22T00:00:00Z"
+                },
                 "hash": {
                     "type": "string",
                     "example": "abc123"
@@ -385,14 +2817,32 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 1
                 },
-                "lifetime": {
-                    "type": "integer",
-                    "example": 90
-                },
                 "number_of_clicks": {
                     "type": "integer",
                     "example": 42
                 },
+                "password_protected": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "purge_at": {
+                    "type": "string",
+                    "example": "2025-05-23T00:00:00Z"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "marketing",
+                        "spring"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Spring campaign"
+                },
                 "updated_at": {
                     "type": "string",
                     "example": "2025-04-23T00:00:00Z"
@@ -407,43 +2857,92 @@ const docTemplate = `{
                 }
             }
         },
-        "link.LinkCreateRequest": {
+        "problem.FieldError": {
+            "description": "Validation failure of a single field",
             "type": "object",
-            "required": [
-                "url"
-            ],
             "properties": {
-                "hash": {
+                "field": {
                     "type": "string",
-                    "example": "custom123"
+                    "example": "url"
                 },
-                "url": {
+                "message": {
                     "type": "string",
-                    "example": "https://example.com"
+                    "example": "url must be a valid URL"
                 },
-                "user_id": {
+                "rule": {
                     "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                    "example": "url"
                 }
             }
         },
-        "link.LinkDeleteRequest": {
+        "problem.Problem": {
+            "description": "RFC 7807 problem details",
             "type": "object",
-            "required": [
-                "hash",
-                "user_id"
-            ],
             "properties": {
-                "hash": {
+                "code": {
                     "type": "string",
-                    "example": "abc123"
+                    "example": "validation_failed"
                 },
-                "user_id": {
+                "detail": {
                     "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                    "example": "The request body has 1 invalid field"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/problem.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/links"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "5f0c3a51-6a8e-4d3c-9a55-0d1d7c3e9b1f"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Validation failed"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:url-shortener:problem:validation_failed"
+                }
+            }
+        },
+        "urlpolicy.BlocklistStats": {
+            "type": "object",
+            "properties": {
+                "domains": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "file": {
+                    "type": "string",
+                    "example": "config/blocklist.txt"
+                },
+                "networks": {
+                    "type": "integer",
+                    "example": 9
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API key issued by POST /api/v1/keys. \"Authorization: Bearer \u003ckey\u003e\" is accepted as well",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BasicAuth": {
+            "type": "basic"
+        }
     }
 }`
 
@@ -454,7 +2953,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "URL Shortener API",
-	Description:      "API for shortening URLs and managing shortened links",
+	Description:      "Operator account configured by http_server.user and http_server.password",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,389 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/internal/auth"
+	"UrlShortenerBackend/internal/link"
+	"UrlShortenerBackend/pkg/middleware"
+	"UrlShortenerBackend/pkg/mux"
+	"UrlShortenerBackend/pkg/problem"
+	"UrlShortenerBackend/pkg/req"
+	"UrlShortenerBackend/pkg/res"
+
+	"github.com/rs/zerolog"
+)
+
+type AdminHandlerDeps struct {
+	LinkStore     link.LinkStore
+	LinkService   *link.LinkService
+	ApiKeyService *auth.ApiKeyService
+	Config        *configs.Config
+	Logger        *zerolog.Logger
+}
+
+type AdminHandler struct {
+	LinkStore     link.LinkStore
+	LinkService   *link.LinkService
+	ApiKeyService *auth.ApiKeyService
+	Logger        *zerolog.Logger
+}
+
+// NewAdminHandler registers the operator API. Every route is protected with
+// the HTTP server user and password from the config.
+func NewAdminHandler(router *mux.Router, deps *AdminHandlerDeps) {
+	handler := &AdminHandler{
+		LinkStore:     deps.LinkStore,
+		LinkService:   deps.LinkService,
+		ApiKeyService: deps.ApiKeyService,
+		Logger:        deps.Logger,
+	}
+
+	basicAuth := middleware.BasicAuth(deps.Config.HTTPServer.User, deps.Config.HTTPServer.Password)
+
+	router.Handle("GET /admin/v1/links", basicAuth(handler.GetAllLinks()))
+	router.Handle("DELETE /admin/v1/links/{id}", basicAuth(handler.ForceDeleteLink()))
+	router.Handle("POST /admin/v1/links/{id}/restore", basicAuth(handler.RestoreLink()))
+	router.Handle("POST /admin/v1/jobs/lifetime", basicAuth(handler.RunLifetimeJob()))
+	router.Handle("POST /admin/v1/jobs/trash-purge", basicAuth(handler.RunTrashPurgeJob()))
+	router.Handle("GET /admin/v1/cache", basicAuth(handler.GetCacheStats()))
+	router.Handle("DELETE /admin/v1/cache", basicAuth(handler.PurgeCache()))
+	router.Handle("POST /admin/v1/users/{user_id}/keys", basicAuth(handler.IssueKey()))
+	router.Handle("POST /admin/v1/url-policy/reload", basicAuth(handler.ReloadUrlBlocklist()))
+}
+
+// GetAllLinks godoc
+// @Summary Search links of all users
+// @Description Lists links across all users, optionally filtered by owner and by a search over hash and destination URL
+// @Tags admin
+// @Produce json
+// @Security BasicAuth
+// @Param q query string false "Substring of the hash or destination URL"
+// @Param user_id query string false "Owner of the links"
+// @Param deleted query string false "Soft-deleted links" Enums(exclude, include, only) default(exclude)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} GetAllLinksResponse "List of links"
+// @Failure 400 {object} problem.Problem "Invalid parameters"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /admin/v1/links [get]
+func (handler *AdminHandler) GetAllLinks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		filter := link.AdminLinkFilter{
+			Query:   query.Get("q"),
+			UserId:  query.Get("user_id"),
+			Deleted: query.Get("deleted"),
+		}
+
+		switch filter.Deleted {
+		case "", link.DELETED_EXCLUDE, link.DELETED_INCLUDE, link.DELETED_ONLY:
+		default:
+			handler.Logger.Error().Str("deleted", filter.Deleted).Msg("Invalid deleted filter")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "deleted must be one of exclude, include or only")
+			return
+		}
+
+		page := link.DEFAULT_PAGE
+		limit := link.DEFAULT_LIMIT
+
+		if pageStr := query.Get("page"); pageStr != "" {
+			if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+				page = p
+			}
+		}
+
+		if limitStr := query.Get("limit"); limitStr != "" {
+			if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+				limit = l
+			}
+		}
+
+		result, err := handler.LinkStore.SearchAllLinks(filter, page, limit)
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Failed to search links")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to search links")
+			return
+		}
+
+		handler.Logger.Info().
+			Str("q", filter.Query).
+			Str("user_id", filter.UserId).
+			Int64("total_links", result.TotalLinks).
+			Msg("Admin link search completed")
+
+		response := GetAllLinksResponse{
+			Links:      result.Links,
+			TotalPages: result.TotalPages,
+			TotalLinks: result.TotalLinks,
+			Page:       result.Page,
+			Limit:      result.Limit,
+		}
+
+		res.Json(w, response, http.StatusOK)
+	}
+}
+
+// ForceDeleteLink godoc
+// @Summary Permanently delete a link
+// @Description Permanently deletes a link, whether or not it was soft-deleted, together with its click history
+// @Tags admin
+// @Produce json
+// @Security BasicAuth
+// @Param id path int true "Link ID"
+// @Success 200 {string} string "Link permanently deleted"
+// @Failure 400 {object} problem.Problem "Invalid link ID"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Failure 404 {object} problem.Problem "Link not found"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /admin/v1/links/{id} [delete]
+func (handler *AdminHandler) ForceDeleteLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Invalid link ID")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Invalid link ID")
+			return
+		}
+
+		err = handler.LinkStore.ForceDeleteLink(uint(id))
+		if err != nil {
+			if errors.Is(err, link.ErrNotFound) {
+				handler.Logger.Warn().Uint64("link_id", id).Msg("Link not found")
+				problem.Respond(w, r, http.StatusNotFound, problem.CODE_NOT_FOUND, "Link not found")
+				return
+			}
+
+			handler.Logger.Error().Err(err).Uint64("link_id", id).Msg("Failed to permanently delete link")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to permanently delete link")
+			return
+		}
+
+		// The hash of the deleted link is unknown here, so start from scratch
+		handler.LinkService.Cache.Purge()
+
+		handler.Logger.Info().Uint64("link_id", id).Msg("Link permanently deleted")
+
+		res.Json(w, "Link permanently deleted", http.StatusOK)
+	}
+}
+
+// RestoreLink godoc
+// @Summary Restore a deleted link
+// @Description Restores a soft-deleted link unless its hash has been taken by another link since. A link restored after its expiry is purged again by the next lifetime job unless its expiry is extended
+// @Tags admin
+// @Produce json
+// @Security BasicAuth
+// @Param id path int true "Link ID"
+// @Success 200 {object} link.Link "Restored link"
+// @Failure 400 {object} problem.Problem "Invalid link ID"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Failure 404 {object} problem.Problem "Deleted link not found"
+// @Failure 409 {object} problem.Problem "Hash already exists"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /admin/v1/links/{id}/restore [post]
+func (handler *AdminHandler) RestoreLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Invalid link ID")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Invalid link ID")
+			return
+		}
+
+		restoredLink, err := handler.LinkStore.RestoreLink(uint(id))
+		if err != nil {
+			if errors.Is(err, link.ErrNotFound) {
+				handler.Logger.Warn().Uint64("link_id", id).Msg("Deleted link not found")
+				problem.Respond(w, r, http.StatusNotFound, problem.CODE_NOT_FOUND, "Deleted link not found")
+				return
+			}
+
+			if errors.Is(err, link.ErrHashTaken) {
+				handler.Logger.Warn().Uint64("link_id", id).Msg("Hash of the deleted link has been reused")
+				problem.Respond(w, r, http.StatusConflict, problem.CODE_HASH_TAKEN, "Hash already exists")
+				return
+			}
+
+			handler.Logger.Error().Err(err).Uint64("link_id", id).Msg("Failed to restore link")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to restore link")
+			return
+		}
+
+		handler.LinkService.Cache.Invalidate(restoredLink.Hash)
+
+		handler.Logger.Info().
+			Uint64("link_id", id).
+			Str("hash", restoredLink.Hash).
+			Msg("Link restored successfully")
+
+		res.Json(w, restoredLink, http.StatusOK)
+	}
+}
+
+// RunLifetimeJob godoc
+// @Summary Run the lifetime job
+// @Description Purges expired links right away instead of waiting for the next scheduled run
+// @Tags admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} LifetimeJobResponse "Number of purged links"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /admin/v1/jobs/lifetime [post]
+func (handler *AdminHandler) RunLifetimeJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deleted, err := handler.LinkService.RunLifetimeUpdate()
+		if err != nil {
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to run lifetime update")
+			return
+		}
+
+		res.Json(w, LifetimeJobResponse{
+			Message:      "Lifetime update completed",
+			DeletedLinks: deleted,
+		}, http.StatusOK)
+	}
+}
+
+// RunTrashPurgeJob godoc
+// @Summary Run the trash purge job
+// @Description Permanently removes the links deleted longer than the trash retention ago, instead of waiting for the next scheduled run
+// @Tags admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} TrashPurgeJobResponse "Number of purged links"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /admin/v1/jobs/trash-purge [post]
+func (handler *AdminHandler) RunTrashPurgeJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		purged, err := handler.LinkService.RunTrashPurge()
+		if err != nil {
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to purge deleted links")
+			return
+		}
+
+		res.Json(w, TrashPurgeJobResponse{
+			Message:     "Trash purge completed",
+			PurgedLinks: purged,
+		}, http.StatusOK)
+	}
+}
+
+// GetCacheStats godoc
+// @Summary Get redirect cache statistics
+// @Description Returns the size, hit, miss and eviction counters of the in-process redirect cache
+// @Tags admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} cache.Stats "Cache statistics"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Router /admin/v1/cache [get]
+func (handler *AdminHandler) GetCacheStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res.Json(w, handler.LinkService.Cache.Stats(), http.StatusOK)
+	}
+}
+
+// PurgeCache godoc
+// @Summary Purge the redirect cache
+// @Description Drops every entry of the in-process redirect cache
+// @Tags admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {string} string "Redirect cache purged"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Router /admin/v1/cache [delete]
+func (handler *AdminHandler) PurgeCache() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler.LinkService.Cache.Purge()
+
+		handler.Logger.Info().Msg("Redirect cache purged")
+
+		res.Json(w, "Redirect cache purged", http.StatusOK)
+	}
+}
+
+// ReloadUrlBlocklist godoc
+// @Summary Reload the URL blocklist
+// @Description Reads the blocklist file of the destination URL policy again. On error the previous blocklist stays in place
+// @Tags admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} urlpolicy.BlocklistStats "Loaded blocklist"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Failure 500 {object} problem.Problem "Blocklist could not be read"
+// @Router /admin/v1/url-policy/reload [post]
+func (handler *AdminHandler) ReloadUrlBlocklist() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policy := handler.LinkService.UrlPolicy
+		if policy == nil || policy.Blocklist == nil {
+			problem.Respond(w, r, http.StatusNotFound, problem.CODE_NOT_FOUND, "No URL blocklist is configured")
+			return
+		}
+
+		stats, err := policy.Blocklist.Reload()
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("file", stats.File).Msg("Failed to reload URL blocklist")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to reload URL blocklist: "+err.Error())
+			return
+		}
+
+		handler.Logger.Info().
+			Int("domains", stats.Domains).
+			Int("networks", stats.Networks).
+			Msg("URL blocklist reloaded")
+
+		res.Json(w, stats, http.StatusOK)
+	}
+}
+
+// IssueKey godoc
+// @Summary Issue an API key for a user
+// @Description Issues an API key for an existing user ID, e.g. for users whose links were created before API keys existed
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param user_id path string true "User ID"
+// @Param payload body IssueKeyRequest false "Key name"
+// @Success 201 {object} auth.ApiKeyCreateResponse "Created key"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Invalid admin credentials"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /admin/v1/users/{user_id}/keys [post]
+func (handler *AdminHandler) IssueKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := r.PathValue("user_id")
+
+		payload := &IssueKeyRequest{}
+		if r.ContentLength != 0 {
+			var err error
+			payload, err = req.HandleBody[IssueKeyRequest](&w, r)
+			if err != nil {
+				handler.Logger.Error().Err(err).Msg("Failed to process issue key request")
+				return
+			}
+		}
+
+		raw, key, err := handler.ApiKeyService.Issue(userId, payload.Name)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Failed to issue API key")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to issue API key")
+			return
+		}
+
+		handler.Logger.Info().
+			Str("user_id", key.UserId).
+			Uint("key_id", key.ID).
+			Msg("API key issued by operator")
+
+		res.Json(w, auth.ApiKeyCreateResponse{Key: raw, ApiKey: *key}, http.StatusCreated)
+	}
+}
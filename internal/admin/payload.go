@@ -0,0 +1,25 @@
+package admin
+
+import "UrlShortenerBackend/internal/link"
+
+type GetAllLinksResponse struct {
+	Links      []link.Link `json:"links"`
+	TotalPages int         `json:"total_pages" example:"5"`
+	TotalLinks int64       `json:"total_links" example:"42"`
+	Page       int         `json:"page" example:"1"`
+	Limit      int         `json:"limit" example:"10"`
+}
+
+type LifetimeJobResponse struct {
+	Message      string `json:"message" example:"Lifetime update completed"`
+	DeletedLinks int64  `json:"deleted_links" example:"3"`
+}
+
+type TrashPurgeJobResponse struct {
+	Message     string `json:"message" example:"Trash purge completed"`
+	PurgedLinks int64  `json:"purged_links" example:"12"`
+}
+
+type IssueKeyRequest struct {
+	Name string `json:"name" validate:"max=100" example:"Migrated from user_id"`
+}
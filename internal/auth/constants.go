@@ -0,0 +1,14 @@
+package auth
+
+import "time"
+
+const (
+	KEY_PREFIX            = "usk_"
+	KEY_BYTES             = 32
+	DISPLAY_PREFIX_LENGTH = 12
+
+	LAST_USED_RESOLUTION = time.Minute
+
+	// Client addresses whose anonymous key creations are counted
+	ANONYMOUS_LIMITER_SIZE = 100000
+)
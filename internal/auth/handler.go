@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/pkg/middleware"
+	"UrlShortenerBackend/pkg/mux"
+	"UrlShortenerBackend/pkg/problem"
+	"UrlShortenerBackend/pkg/ratelimit"
+	"UrlShortenerBackend/pkg/req"
+	"UrlShortenerBackend/pkg/res"
+
+	"github.com/rs/zerolog"
+)
+
+type ApiKeyHandlerDeps struct {
+	ApiKeyService *ApiKeyService
+	ClientIps     *click.ClientIpResolver
+	Config        *configs.Config
+	Logger        *zerolog.Logger
+}
+
+type ApiKeyHandler struct {
+	ApiKeyService *ApiKeyService
+	ClientIps     *click.ClientIpResolver
+	Logger        *zerolog.Logger
+	anonymous     *ratelimit.Limiter
+}
+
+func NewApiKeyHandler(router *mux.Router, deps *ApiKeyHandlerDeps) {
+	handler := &ApiKeyHandler{
+		ApiKeyService: deps.ApiKeyService,
+		ClientIps:     deps.ClientIps,
+		Logger:        deps.Logger,
+		anonymous: ratelimit.New(
+			deps.Config.ApiKeys.AnonymousLimit,
+			deps.Config.ApiKeys.AnonymousWindow,
+			ANONYMOUS_LIMITER_SIZE,
+		),
+	}
+
+	router.HandleFunc("POST /api/v1/keys", handler.CreateKey())
+	router.HandleFunc("GET /api/v1/keys", handler.GetAllKeys())
+	router.HandleFunc("DELETE /api/v1/keys/{id}", handler.RevokeKey())
+}
+
+// CreateKey godoc
+// @Summary Create an API key
+// @Description Issues a new API key. Authenticated callers get an additional key for their own user, anonymous callers get a key for a newly created user, a limited number of times per client address. The key is only returned once
+// @Tags keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body ApiKeyCreateRequest false "Key name"
+// @Success 201 {object} ApiKeyCreateResponse "Created key"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Invalid API key"
+// @Failure 429 {object} problem.Problem "Too many keys created anonymously from this address"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/keys [post]
+func (handler *ApiKeyHandler) CreateKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload := &ApiKeyCreateRequest{}
+		if r.ContentLength != 0 {
+			var err error
+			payload, err = req.HandleBody[ApiKeyCreateRequest](&w, r)
+			if err != nil {
+				handler.Logger.Error().Err(err).Msg("Failed to process create key request")
+				return
+			}
+		}
+
+		userId, _ := middleware.UserIdFromContext(r.Context())
+		if userId == "" {
+			ip := handler.ClientIps.ClientIp(r)
+			if wait := handler.anonymous.Take(ip); wait > 0 {
+				handler.Logger.Warn().Str("ip", ip).Dur("retry_after", wait).Msg("Too many anonymous API keys")
+				w.Header().Set("Retry-After", strconv.Itoa(int(wait.Round(time.Second).Seconds())))
+				problem.Respond(w, r, http.StatusTooManyRequests, problem.CODE_RATE_LIMITED, "Too many API keys created from this address, try again later")
+				return
+			}
+		}
+
+		raw, key, err := handler.ApiKeyService.Issue(userId, payload.Name)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Failed to create API key")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to create API key")
+			return
+		}
+
+		handler.Logger.Info().
+			Str("user_id", key.UserId).
+			Uint("key_id", key.ID).
+			Bool("new_user", userId == "").
+			Msg("API key created successfully")
+
+		res.Json(w, ApiKeyCreateResponse{Key: raw, ApiKey: *key}, http.StatusCreated)
+	}
+}
+
+// GetAllKeys godoc
+// @Summary List API keys
+// @Description Lists the API keys of the authenticated user, including revoked ones
+// @Tags keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} GetAllApiKeysResponse "List of keys"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/keys [get]
+func (handler *ApiKeyHandler) GetAllKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		keys, err := handler.ApiKeyService.Repository.GetAllByUser(userId)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Failed to get API keys")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve API keys")
+			return
+		}
+
+		res.Json(w, GetAllApiKeysResponse{Keys: keys}, http.StatusOK)
+	}
+}
+
+// RevokeKey godoc
+// @Summary Revoke an API key
+// @Description Revokes one of the authenticated user's API keys
+// @Tags keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Key ID"
+// @Success 200 {string} string "API key revoked successfully"
+// @Failure 400 {object} problem.Problem "Invalid key ID"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 404 {object} problem.Problem "API key not found"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/keys/{id} [delete]
+func (handler *ApiKeyHandler) RevokeKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Invalid key ID")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Invalid key ID")
+			return
+		}
+
+		revoked, err := handler.ApiKeyService.Repository.Revoke(uint(id), userId)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Failed to revoke API key")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to revoke API key")
+			return
+		}
+
+		if !revoked {
+			handler.Logger.Warn().Str("user_id", userId).Uint64("key_id", id).Msg("API key not found")
+			problem.Respond(w, r, http.StatusNotFound, problem.CODE_NOT_FOUND, "API key not found")
+			return
+		}
+
+		handler.Logger.Info().Str("user_id", userId).Uint64("key_id", id).Msg("API key revoked successfully")
+
+		res.Json(w, "API key revoked successfully", http.StatusOK)
+	}
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryApiKeyStore keeps API keys in process, for local runs and tests.
+type MemoryApiKeyStore struct {
+	mutex  sync.RWMutex
+	keys   map[uint]*ApiKey
+	nextId uint
+}
+
+func NewMemoryApiKeyStore() *MemoryApiKeyStore {
+	return &MemoryApiKeyStore{
+		keys:   make(map[uint]*ApiKey),
+		nextId: 1,
+	}
+}
+
+func (store *MemoryApiKeyStore) Create(key *ApiKey) (*ApiKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now().UTC()
+	key.ID = store.nextId
+	key.CreatedAt = now
+	key.UpdatedAt = now
+	store.nextId++
+
+	stored := *key
+	store.keys[key.ID] = &stored
+
+	return key, nil
+}
+
+func (store *MemoryApiKeyStore) GetActiveByHash(keyHash string) (*ApiKey, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, key := range store.keys {
+		if key.KeyHash == keyHash && key.RevokedAt == nil {
+			found := *key
+			return &found, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (store *MemoryApiKeyStore) GetAllByUser(userId string) ([]ApiKey, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	keys := []ApiKey{}
+	for _, key := range store.keys {
+		if key.UserId == userId {
+			keys = append(keys, *key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (store *MemoryApiKeyStore) Revoke(id uint, userId string) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key, ok := store.keys[id]
+	if !ok || key.UserId != userId || key.RevokedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	key.UpdatedAt = now
+
+	return true, nil
+}
+
+func (store *MemoryApiKeyStore) TouchLastUsed(id uint, usedAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if key, ok := store.keys[id]; ok {
+		key.LastUsedAt = &usedAt
+	}
+
+	return nil
+}
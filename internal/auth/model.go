@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// ApiKey represents an API key issued to a user. Only the SHA-256 of the key
+// is stored, the key itself is shown once when it is created.
+// @Description API key model
+type ApiKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey" example:"1"`
+	CreatedAt  time.Time  `json:"created_at" example:"2025-04-23T00:00:00Z"`
+	UpdatedAt  time.Time  `json:"updated_at" example:"2025-04-23T00:00:00Z"`
+	UserId     string     `json:"user_id" gorm:"not null;index" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Name       string     `json:"name" example:"CI pipeline"`
+	Prefix     string     `json:"prefix" example:"usk_Ab3dE6gH"`
+	KeyHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	LastUsedAt *time.Time `json:"last_used_at" example:"2025-04-23T00:00:00Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" example:"2025-04-23T00:00:00Z"`
+}
+
+// GenerateKey returns a new random API key.
+func GenerateKey() (string, error) {
+	b := make([]byte, KEY_BYTES)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return KEY_PREFIX + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// DisplayPrefix is the part of the key kept in clear so users can tell
+// their keys apart.
+func DisplayPrefix(key string) string {
+	if len(key) <= DISPLAY_PREFIX_LENGTH {
+		return key
+	}
+
+	return key[:DISPLAY_PREFIX_LENGTH]
+}
@@ -0,0 +1,14 @@
+package auth
+
+type ApiKeyCreateRequest struct {
+	Name string `json:"name" validate:"max=100" example:"CI pipeline"`
+}
+
+type ApiKeyCreateResponse struct {
+	Key    string `json:"key" example:"usk_Ab3dE6gHjK9mNpQrStUvWxYz0123456789abcdefg"`
+	ApiKey ApiKey `json:"api_key"`
+}
+
+type GetAllApiKeysResponse struct {
+	Keys []ApiKey `json:"keys"`
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"time"
+
+	"UrlShortenerBackend/pkg/db"
+)
+
+type ApiKeyRepository struct {
+	Database *db.Db
+}
+
+func NewApiKeyRepository(database *db.Db) *ApiKeyRepository {
+	return &ApiKeyRepository{
+		Database: database,
+	}
+}
+
+func (repo *ApiKeyRepository) Create(key *ApiKey) (*ApiKey, error) {
+	result := repo.Database.DB.Create(key)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return key, nil
+}
+
+func (repo *ApiKeyRepository) GetActiveByHash(keyHash string) (*ApiKey, error) {
+	var key ApiKey
+	result := repo.Database.DB.Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &key, nil
+}
+
+func (repo *ApiKeyRepository) GetAllByUser(userId string) ([]ApiKey, error) {
+	keys := []ApiKey{}
+	result := repo.Database.DB.Where("user_id = ?", userId).Order("created_at DESC").Find(&keys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return keys, nil
+}
+
+// Revoke marks the key as revoked and reports whether a key of the user was
+// actually revoked.
+func (repo *ApiKeyRepository) Revoke(id uint, userId string) (bool, error) {
+	result := repo.Database.DB.Model(&ApiKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *ApiKeyRepository) TouchLastUsed(id uint, usedAt time.Time) error {
+	result := repo.Database.DB.Model(&ApiKey{}).Where("id = ?", id).Update("last_used_at", usedAt)
+	return result.Error
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"UrlShortenerBackend/pkg/middleware"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+type ApiKeyService struct {
+	Repository ApiKeyStore
+	Logger     *zerolog.Logger
+}
+
+func NewApiKeyService(repository ApiKeyStore, logger *zerolog.Logger) *ApiKeyService {
+	return &ApiKeyService{
+		Repository: repository,
+		Logger:     logger,
+	}
+}
+
+// Issue creates a key for the user, or for a brand new user when userId is
+// empty. The returned string is the only time the key is available in clear.
+func (s *ApiKeyService) Issue(userId, name string) (string, *ApiKey, error) {
+	if userId == "" {
+		userId = uuid.New().String()
+	}
+
+	raw, err := GenerateKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, err := s.Repository.Create(&ApiKey{
+		UserId:  userId,
+		Name:    name,
+		Prefix:  DisplayPrefix(raw),
+		KeyHash: HashKey(raw),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return raw, key, nil
+}
+
+// Authenticate resolves a raw key to the user it belongs to. It satisfies
+// middleware.Authenticator.
+func (s *ApiKeyService) Authenticate(raw string) (string, error) {
+	key, err := s.Repository.GetActiveByHash(HashKey(raw))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", middleware.ErrInvalidApiKey
+		}
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= LAST_USED_RESOLUTION {
+		if err := s.Repository.TouchLastUsed(key.ID, now); err != nil {
+			s.Logger.Warn().Err(err).Uint("key_id", key.ID).Msg("Failed to update API key last use")
+		}
+	}
+
+	return key.UserId, nil
+}
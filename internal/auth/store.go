@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"time"
+
+	"UrlShortenerBackend/pkg/db"
+)
+
+// ApiKeyStore persists API keys. ApiKeyRepository works on Postgres and
+// SQLite, MemoryApiKeyStore keeps keys in process. Lookups of missing keys
+// return gorm.ErrRecordNotFound whatever the backend.
+type ApiKeyStore interface {
+	Create(key *ApiKey) (*ApiKey, error)
+	GetActiveByHash(keyHash string) (*ApiKey, error)
+	GetAllByUser(userId string) ([]ApiKey, error)
+	Revoke(id uint, userId string) (bool, error)
+	TouchLastUsed(id uint, usedAt time.Time) error
+}
+
+// NewApiKeyStore returns the store for the configured database driver. The
+// database is nil for the memory driver.
+func NewApiKeyStore(driver string, database *db.Db) ApiKeyStore {
+	if driver == db.DRIVER_MEMORY {
+		return NewMemoryApiKeyStore()
+	}
+
+	return NewApiKeyRepository(database)
+}
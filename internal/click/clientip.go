@@ -0,0 +1,118 @@
+package click
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIpResolver finds the address of the original client. The
+// X-Forwarded-For header can be set by anyone, so it is only read when the
+// request comes from one of the trusted proxies, and then from the right:
+// the first hop that is not a trusted proxy is the client.
+type ClientIpResolver struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIpResolver trusts the given proxies, IP addresses or CIDR
+// ranges. Without any, X-Forwarded-For is ignored.
+func NewClientIpResolver(proxies []string) (*ClientIpResolver, error) {
+	resolver := &ClientIpResolver{}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			resolver.trusted = append(resolver.trusted, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q, expected an IP address or a CIDR range", proxy)
+		}
+		addr = addr.Unmap()
+		resolver.trusted = append(resolver.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return resolver, nil
+}
+
+func (c *ClientIpResolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteAddr returns the address r came from, the raw RemoteAddr host
+// when it is not an IP address.
+func remoteAddr(r *http.Request) (netip.Addr, string) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, host
+	}
+	return addr.Unmap(), host
+}
+
+// Secure reports whether the client of r uses HTTPS: the connection is TLS
+// or a trusted proxy says so in X-Forwarded-Proto.
+func (c *ClientIpResolver) Secure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+
+	proxy, _ := remoteAddr(r)
+	if !proxy.IsValid() || !c.isTrusted(proxy) {
+		return false
+	}
+
+	// The proxy talking to us is the last one to have set the header
+	protos := strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(protos[len(protos)-1]), "https")
+}
+
+// ClientIp returns the address of the client of r.
+func (c *ClientIpResolver) ClientIp(r *http.Request) string {
+	client, host := remoteAddr(r)
+	if !client.IsValid() {
+		return host
+	}
+
+	if !c.isTrusted(client) {
+		return client.String()
+	}
+
+	// Every proxy appends the address it got the request from
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Garbage left of the trusted hops, the last one we could vouch
+			// for is the best we have
+			break
+		}
+
+		client = hop.Unmap()
+		if !c.isTrusted(client) {
+			break
+		}
+	}
+
+	return client.String()
+}
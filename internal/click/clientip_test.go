@@ -0,0 +1,84 @@
+package click
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIp(t *testing.T) {
+	resolver, err := NewClientIpResolver([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer spoofing", "203.0.113.7:5000", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:443", []string{"198.51.100.4"}, "198.51.100.4"},
+		{"rightmost untrusted hop", "10.1.2.3:443", []string{"1.2.3.4, 198.51.100.4, 192.0.2.1"}, "198.51.100.4"},
+		{"several headers", "10.1.2.3:443", []string{"1.2.3.4", "198.51.100.4"}, "198.51.100.4"},
+		{"only proxies", "10.1.2.3:443", []string{"10.9.9.9"}, "10.9.9.9"},
+		{"garbage hop", "10.1.2.3:443", []string{"nonsense, 10.9.9.9"}, "10.9.9.9"},
+		{"no header from proxy", "192.0.2.1:80", nil, "192.0.2.1"},
+		{"mapped v4", "[::ffff:203.0.113.7]:80", nil, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/abc", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := resolver.ClientIp(r); got != tt.want {
+				t.Errorf("ClientIp() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecure(t *testing.T) {
+	resolver, err := NewClientIpResolver([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		proto      string
+		want       bool
+	}{
+		{"plain", "203.0.113.7:5000", "", false},
+		{"untrusted peer spoofing", "203.0.113.7:5000", "https", false},
+		{"trusted proxy", "10.1.2.3:443", "https", true},
+		{"trusted proxy over http", "10.1.2.3:443", "http", false},
+		{"last proxy wins", "10.1.2.3:443", "https, http", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/abc", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.proto != "" {
+				r.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+
+			if got := resolver.Secure(r); got != tt.want {
+				t.Errorf("Secure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientIpResolverRejectsInvalidProxy(t *testing.T) {
+	if _, err := NewClientIpResolver([]string{"not-an-ip"}); err == nil {
+		t.Error("expected an error for an invalid proxy")
+	}
+}
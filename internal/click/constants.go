@@ -0,0 +1,13 @@
+package click
+
+const (
+	DEFAULT_PAGE  = 1
+	DEFAULT_LIMIT = 50
+
+	DEFAULT_STATS_RANGE_DAYS = 7
+	DEFAULT_STATS_TOP        = 10
+	MAX_STATS_TOP            = 100
+	MAX_STATS_BUCKETS        = 1000
+
+	DIRECT_REFERRER = "direct"
+)
@@ -0,0 +1,107 @@
+package click
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemoryClickStore keeps click events in process, for local runs and tests.
+type MemoryClickStore struct {
+	mutex  sync.RWMutex
+	events []ClickEvent
+	nextId uint
+}
+
+func NewMemoryClickStore() *MemoryClickStore {
+	return &MemoryClickStore{
+		nextId: 1,
+	}
+}
+
+func (store *MemoryClickStore) Create(event *ClickEvent) error {
+	return store.CreateBatch([]*ClickEvent{event})
+}
+
+func (store *MemoryClickStore) CreateBatch(events []*ClickEvent) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, event := range events {
+		event.ID = store.nextId
+		store.nextId++
+		store.events = append(store.events, *event)
+	}
+
+	return nil
+}
+
+func (store *MemoryClickStore) GetByLinkId(linkId uint, page, limit int) (*PaginationResult, error) {
+	if page <= 0 {
+		page = DEFAULT_PAGE
+	}
+
+	if limit <= 0 {
+		limit = DEFAULT_LIMIT
+	}
+
+	store.mutex.RLock()
+	events := []ClickEvent{}
+	for _, event := range store.events {
+		if event.LinkId == linkId {
+			events = append(events, event)
+		}
+	}
+	store.mutex.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+
+	totalEvents := int64(len(events))
+	start := min((page-1)*limit, len(events))
+	end := min(start+limit, len(events))
+
+	return &PaginationResult{
+		Events:      events[start:end],
+		TotalEvents: totalEvents,
+		TotalPages:  (len(events) + limit - 1) / limit,
+		Page:        page,
+		Limit:       limit,
+	}, nil
+}
+
+func (store *MemoryClickStore) GetStats(query StatsQuery) (*Stats, error) {
+	top := query.Top
+	if top <= 0 {
+		top = DEFAULT_STATS_TOP
+	}
+
+	store.mutex.RLock()
+	events := []ClickEvent{}
+	for _, event := range store.events {
+		if event.LinkId == query.LinkId && !event.CreatedAt.Before(query.From) && event.CreatedAt.Before(query.To) {
+			events = append(events, event)
+		}
+	}
+	store.mutex.RUnlock()
+
+	visitors := make(map[string]bool)
+	referrers := make([]string, 0, len(events))
+	families := make([]string, 0, len(events))
+	for _, event := range events {
+		visitors[event.IpHash] = true
+		referrers = append(referrers, referrerOrDirect(event.Referrer))
+		families = append(families, familyOrUnknown(event.UserAgentFamily))
+	}
+
+	return &Stats{
+		Interval:       query.Interval,
+		From:           query.From,
+		To:             query.To,
+		TotalClicks:    int64(len(events)),
+		UniqueVisitors: int64(len(visitors)),
+		Buckets:        fillBuckets(bucketEvents(events, query.Interval), query.Interval, query.From, query.To),
+		TopReferrers:   topValues(referrers, top),
+		TopUserAgents:  topValues(families, top),
+	}, nil
+}
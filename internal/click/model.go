@@ -0,0 +1,49 @@
+package click
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// ClickEvent represents a single redirect through a shortened link
+// @Description Click event model
+type ClickEvent struct {
+	ID              uint      `json:"id" gorm:"primaryKey" example:"1"`
+	CreatedAt       time.Time `json:"created_at" gorm:"index:idx_click_events_link_created,priority:2" example:"2025-04-23T00:00:00Z"`
+	LinkId          uint      `json:"link_id" gorm:"not null;index:idx_click_events_link_created,priority:1" example:"1"`
+	Hash            string    `json:"hash" example:"abc123"`
+	Referrer        string    `json:"referrer" example:"https://google.com/"`
+	UserAgent       string    `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64)"`
+	UserAgentFamily string    `json:"user_agent_family" example:"Chrome"`
+	AcceptLanguage  string    `json:"accept_language" example:"en-US,en;q=0.9"`
+	IpHash          string    `json:"ip_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// NewClickEvent records a redirect of r, clientIp is hashed with ipSalt.
+func NewClickEvent(linkId uint, hash string, r *http.Request, clientIp, ipSalt string) *ClickEvent {
+	return &ClickEvent{
+		CreatedAt:       time.Now().UTC(),
+		LinkId:          linkId,
+		Hash:            hash,
+		Referrer:        r.Referer(),
+		UserAgent:       r.UserAgent(),
+		UserAgentFamily: UserAgentFamily(r.UserAgent()),
+		AcceptLanguage:  r.Header.Get("Accept-Language"),
+		IpHash:          HashIp(clientIp, ipSalt),
+	}
+}
+
+// HashIp hashes the client address with HMAC-SHA256 keyed by salt, so raw
+// IPs are never persisted and cannot be recovered without the salt.
+func HashIp(ip, salt string) string {
+	if ip == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,27 @@
+package click
+
+import "testing"
+
+func TestHashIp(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		salt string
+		want string
+	}{
+		// RFC 4231 test case 2, HMAC-SHA256 with key "Jefe"
+		{"hmac", "what do ya want for nothing?", "Jefe", "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{"no address", "", "salt", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HashIp(tt.ip, tt.salt); got != tt.want {
+				t.Errorf("HashIp() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if HashIp("203.0.113.7", "one") == HashIp("203.0.113.7", "two") {
+		t.Error("HashIp() does not depend on the salt")
+	}
+}
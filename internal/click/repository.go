@@ -0,0 +1,162 @@
+package click
+
+import (
+	"UrlShortenerBackend/pkg/db"
+
+	"gorm.io/gorm"
+)
+
+type PaginationResult struct {
+	Events      []ClickEvent
+	TotalEvents int64
+	TotalPages  int
+	Page        int
+	Limit       int
+}
+
+type ClickRepository struct {
+	Database *db.Db
+}
+
+func NewClickRepository(database *db.Db) *ClickRepository {
+	return &ClickRepository{
+		Database: database,
+	}
+}
+
+func (repo *ClickRepository) Create(event *ClickEvent) error {
+	result := repo.Database.DB.Create(event)
+	return result.Error
+}
+
+func (repo *ClickRepository) CreateBatch(events []*ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	result := repo.Database.DB.CreateInBatches(events, len(events))
+	return result.Error
+}
+
+func (repo *ClickRepository) GetByLinkId(linkId uint, page, limit int) (*PaginationResult, error) {
+	if page <= 0 {
+		page = DEFAULT_PAGE
+	}
+
+	if limit <= 0 {
+		limit = DEFAULT_LIMIT
+	}
+
+	offset := (page - 1) * limit
+
+	var totalEvents int64
+	countResult := repo.Database.DB.Model(&ClickEvent{}).Where("link_id = ?", linkId).Count(&totalEvents)
+	if countResult.Error != nil {
+		return nil, countResult.Error
+	}
+
+	events := []ClickEvent{}
+	if totalEvents > 0 {
+		result := repo.Database.DB.Where("link_id = ?", linkId).Order("created_at DESC").Offset(offset).Limit(limit).Find(&events)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+
+	totalPages := (int(totalEvents) + limit - 1) / limit
+
+	return &PaginationResult{
+		Events:      events,
+		TotalEvents: totalEvents,
+		TotalPages:  totalPages,
+		Page:        page,
+		Limit:       limit,
+	}, nil
+}
+
+func (repo *ClickRepository) GetStats(query StatsQuery) (*Stats, error) {
+	top := query.Top
+	if top <= 0 {
+		top = DEFAULT_STATS_TOP
+	}
+
+	scope := repo.Database.DB.Model(&ClickEvent{}).
+		Where("link_id = ? AND created_at >= ? AND created_at < ?", query.LinkId, query.From, query.To)
+
+	var totals struct {
+		TotalClicks    int64
+		UniqueVisitors int64
+	}
+	result := scope.Session(&gorm.Session{}).
+		Select("COUNT(*) AS total_clicks, COUNT(DISTINCT ip_hash) AS unique_visitors").
+		Scan(&totals)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	buckets, err := repo.getBuckets(scope.Session(&gorm.Session{}), query.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	topReferrers := []CountEntry{}
+	result = scope.Session(&gorm.Session{}).
+		Select("COALESCE(NULLIF(referrer, ''), ?) AS value, COUNT(*) AS clicks", DIRECT_REFERRER).
+		Group("value").
+		Order("clicks DESC, value").
+		Limit(top).
+		Scan(&topReferrers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	topUserAgents := []CountEntry{}
+	result = scope.Session(&gorm.Session{}).
+		Select("COALESCE(NULLIF(user_agent_family, ''), ?) AS value, COUNT(*) AS clicks", UNKNOWN_USER_AGENT_FAMILY).
+		Group("value").
+		Order("clicks DESC, value").
+		Limit(top).
+		Scan(&topUserAgents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &Stats{
+		Interval:       query.Interval,
+		From:           query.From,
+		To:             query.To,
+		TotalClicks:    totals.TotalClicks,
+		UniqueVisitors: totals.UniqueVisitors,
+		Buckets:        fillBuckets(buckets, query.Interval, query.From, query.To),
+		TopReferrers:   topReferrers,
+		TopUserAgents:  topUserAgents,
+	}, nil
+}
+
+// getBuckets groups clicks with date_trunc on Postgres, in UTC whatever the
+// session time zone so they line up with the buckets of fillBuckets. SQLite
+// has no equivalent for timestamps stored as text, so there the rows are
+// bucketed in Go.
+func (repo *ClickRepository) getBuckets(scope *gorm.DB, interval Interval) ([]Bucket, error) {
+	if repo.Database.IsSQLite() {
+		var events []ClickEvent
+		result := scope.Select("created_at, ip_hash").Find(&events)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		return bucketEvents(events, interval), nil
+	}
+
+	var buckets []Bucket
+	result := scope.
+		Select("date_trunc(?, created_at AT TIME ZONE 'UTC') AS start, COUNT(*) AS clicks, COUNT(DISTINCT ip_hash) AS unique_visitors", string(interval)).
+		Group("start").
+		Order("start").
+		Scan(&buckets)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return buckets, nil
+}
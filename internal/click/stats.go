@@ -0,0 +1,194 @@
+package click
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+type Interval string
+
+const (
+	INTERVAL_HOUR Interval = "hour"
+	INTERVAL_DAY  Interval = "day"
+	INTERVAL_WEEK Interval = "week"
+)
+
+var ErrInvalidInterval = errors.New("interval must be one of hour, day or week")
+
+func ParseInterval(value string) (Interval, error) {
+	switch Interval(value) {
+	case INTERVAL_HOUR, INTERVAL_DAY, INTERVAL_WEEK:
+		return Interval(value), nil
+	case "":
+		return INTERVAL_DAY, nil
+	}
+
+	return "", ErrInvalidInterval
+}
+
+func (interval Interval) Duration() time.Duration {
+	switch interval {
+	case INTERVAL_HOUR:
+		return time.Hour
+	case INTERVAL_WEEK:
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// Truncate aligns t to the start of its bucket the same way Postgres
+// date_trunc does, weeks starting on Monday.
+func (interval Interval) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch interval {
+	case INTERVAL_HOUR:
+		return t.Truncate(time.Hour)
+	case INTERVAL_WEEK:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Bucket holds the clicks that happened in one time slot
+// @Description Clicks aggregated over one time bucket
+type Bucket struct {
+	Start          time.Time `json:"start" example:"2025-04-23T00:00:00Z"`
+	Clicks         int64     `json:"clicks" example:"12"`
+	UniqueVisitors int64     `json:"unique_visitors" example:"9"`
+}
+
+// CountEntry is a single row of a top-N breakdown
+// @Description Value with its number of clicks
+type CountEntry struct {
+	Value  string `json:"value" example:"https://google.com/"`
+	Clicks int64  `json:"clicks" example:"7"`
+}
+
+type StatsQuery struct {
+	LinkId   uint
+	Interval Interval
+	From     time.Time
+	To       time.Time
+	Top      int
+}
+
+type Stats struct {
+	Interval       Interval
+	From           time.Time
+	To             time.Time
+	TotalClicks    int64
+	UniqueVisitors int64
+	Buckets        []Bucket
+	TopReferrers   []CountEntry
+	TopUserAgents  []CountEntry
+}
+
+// fillBuckets returns one bucket per slot between from and to, keeping the
+// counts found in the database and zeroing the slots without clicks.
+func fillBuckets(found []Bucket, interval Interval, from, to time.Time) []Bucket {
+	byStart := make(map[int64]Bucket, len(found))
+	for _, bucket := range found {
+		byStart[bucket.Start.UTC().Unix()] = bucket
+	}
+
+	buckets := []Bucket{}
+	for start := interval.Truncate(from); start.Before(to); start = nextBucket(start, interval) {
+		bucket, ok := byStart[start.Unix()]
+		if !ok {
+			bucket = Bucket{Start: start}
+		}
+		bucket.Start = start
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets
+}
+
+func nextBucket(start time.Time, interval Interval) time.Time {
+	switch interval {
+	case INTERVAL_HOUR:
+		return start.Add(time.Hour)
+	case INTERVAL_WEEK:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// bucketEvents groups events by interval in Go, for backends without
+// date_trunc.
+func bucketEvents(events []ClickEvent, interval Interval) []Bucket {
+	visitors := make(map[int64]map[string]bool)
+	byStart := make(map[int64]*Bucket)
+	buckets := []Bucket{}
+
+	for _, event := range events {
+		start := interval.Truncate(event.CreatedAt)
+		key := start.Unix()
+
+		bucket, ok := byStart[key]
+		if !ok {
+			bucket = &Bucket{Start: start}
+			byStart[key] = bucket
+			visitors[key] = make(map[string]bool)
+		}
+
+		bucket.Clicks++
+		if !visitors[key][event.IpHash] {
+			visitors[key][event.IpHash] = true
+			bucket.UniqueVisitors++
+		}
+	}
+
+	for _, bucket := range byStart {
+		buckets = append(buckets, *bucket)
+	}
+
+	return buckets
+}
+
+// topValues counts values and keeps the most frequent ones, ties broken
+// alphabetically like the SQL queries do.
+func topValues(values []string, top int) []CountEntry {
+	counts := make(map[string]int64)
+	for _, value := range values {
+		counts[value]++
+	}
+
+	entries := make([]CountEntry, 0, len(counts))
+	for value, clicks := range counts {
+		entries = append(entries, CountEntry{Value: value, Clicks: clicks})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Clicks == entries[j].Clicks {
+			return entries[i].Value < entries[j].Value
+		}
+		return entries[i].Clicks > entries[j].Clicks
+	})
+
+	if len(entries) > top {
+		entries = entries[:top]
+	}
+
+	return entries
+}
+
+func referrerOrDirect(referrer string) string {
+	if referrer == "" {
+		return DIRECT_REFERRER
+	}
+	return referrer
+}
+
+func familyOrUnknown(family string) string {
+	if family == "" {
+		return UNKNOWN_USER_AGENT_FAMILY
+	}
+	return family
+}
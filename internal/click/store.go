@@ -0,0 +1,22 @@
+package click
+
+import "UrlShortenerBackend/pkg/db"
+
+// ClickStore persists click events. ClickRepository works on Postgres and
+// SQLite, MemoryClickStore keeps events in process.
+type ClickStore interface {
+	Create(event *ClickEvent) error
+	CreateBatch(events []*ClickEvent) error
+	GetByLinkId(linkId uint, page, limit int) (*PaginationResult, error)
+	GetStats(query StatsQuery) (*Stats, error)
+}
+
+// NewClickStore returns the store for the configured database driver. The
+// database is nil for the memory driver.
+func NewClickStore(driver string, database *db.Db) ClickStore {
+	if driver == db.DRIVER_MEMORY {
+		return NewMemoryClickStore()
+	}
+
+	return NewClickRepository(database)
+}
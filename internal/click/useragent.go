@@ -0,0 +1,50 @@
+package click
+
+import "strings"
+
+const UNKNOWN_USER_AGENT_FAMILY = "Unknown"
+
+// userAgentFamilies is checked in order, so more specific tokens have to
+// come before the generic ones they contain (Edge and Opera embed "Chrome",
+// Chrome embeds "Safari").
+var userAgentFamilies = []struct {
+	token  string
+	family string
+}{
+	{"bot", "Bot"},
+	{"crawler", "Bot"},
+	{"spider", "Bot"},
+	{"curl/", "curl"},
+	{"wget/", "Wget"},
+	{"postmanruntime/", "Postman"},
+	{"edg/", "Edge"},
+	{"edge/", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"yabrowser/", "Yandex Browser"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"chromium/", "Chrome"},
+	{"safari/", "Safari"},
+	{"msie ", "Internet Explorer"},
+	{"trident/", "Internet Explorer"},
+}
+
+// UserAgentFamily reduces a raw User-Agent header to a browser family name.
+func UserAgentFamily(userAgent string) string {
+	if userAgent == "" {
+		return UNKNOWN_USER_AGENT_FAMILY
+	}
+
+	lowered := strings.ToLower(userAgent)
+	for _, candidate := range userAgentFamilies {
+		if strings.Contains(lowered, candidate.token) {
+			return candidate.family
+		}
+	}
+
+	return "Other"
+}
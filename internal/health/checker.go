@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	STATUS_OK   = "ok"
+	STATUS_FAIL = "fail"
+
+	CHECK_SHUTDOWN = "shutdown"
+
+	DEFAULT_CHECK_TIMEOUT = 2 * time.Second
+)
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// CheckFunc probes one dependency and returns nil when it is healthy.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of a single check
+// @Description Outcome of a single health check
+type CheckResult struct {
+	Status    string  `json:"status" example:"ok"`
+	LatencyMs float64 `json:"latency_ms" example:"0.42"`
+	Error     string  `json:"error,omitempty" example:"dial tcp 127.0.0.1:5432: connect: connection refused"`
+}
+
+// Report aggregates the checks of a probe, Status is "fail" when any check failed
+// @Description Health probe report
+type Report struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker runs the liveness and readiness checks. Readiness fails as soon as
+// shutdown begins, so load balancers stop routing before the server closes.
+type Checker struct {
+	liveness     []namedCheck
+	readiness    []namedCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DEFAULT_CHECK_TIMEOUT
+	}
+
+	return &Checker{
+		timeout: timeout,
+	}
+}
+
+func (c *Checker) AddLivenessCheck(name string, check CheckFunc) {
+	c.liveness = append(c.liveness, namedCheck{name: name, check: check})
+}
+
+func (c *Checker) AddReadinessCheck(name string, check CheckFunc) {
+	c.readiness = append(c.readiness, namedCheck{name: name, check: check})
+}
+
+// StartShutdown makes every following readiness probe fail.
+func (c *Checker) StartShutdown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Liveness(ctx context.Context) *Report {
+	return c.run(ctx, c.liveness)
+}
+
+func (c *Checker) Readiness(ctx context.Context) *Report {
+	checks := c.readiness
+	if c.shuttingDown.Load() {
+		checks = append([]namedCheck{{name: CHECK_SHUTDOWN, check: shutdownCheck}}, checks...)
+	}
+
+	return c.run(ctx, checks)
+}
+
+// run executes the checks concurrently, each bounded by the checker timeout.
+func (c *Checker) run(ctx context.Context, checks []namedCheck) *Report {
+	report := &Report{
+		Status: STATUS_OK,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, named := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := runCheck(ctx, named.check, c.timeout)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Checks[named.name] = result
+			if result.Status != STATUS_OK {
+				report.Status = STATUS_FAIL
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func runCheck(ctx context.Context, check CheckFunc, timeout time.Duration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:    STATUS_OK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = STATUS_FAIL
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func shutdownCheck(ctx context.Context) error {
+	return ErrShuttingDown
+}
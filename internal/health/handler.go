@@ -0,0 +1,74 @@
+package health
+
+import (
+	"net/http"
+
+	"UrlShortenerBackend/pkg/mux"
+	"UrlShortenerBackend/pkg/res"
+
+	"github.com/rs/zerolog"
+)
+
+type HealthHandlerDeps struct {
+	Checker *Checker
+	Logger  *zerolog.Logger
+}
+
+type HealthHandler struct {
+	Checker *Checker
+	Logger  *zerolog.Logger
+}
+
+func NewHealthHandler(router *mux.Router, deps *HealthHandlerDeps) {
+	handler := &HealthHandler{
+		Checker: deps.Checker,
+		Logger:  deps.Logger,
+	}
+
+	router.HandleFunc("GET /healthz", handler.Healthz())
+	router.HandleFunc("GET /readyz", handler.Readyz())
+}
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Reports that the process is alive and serving requests
+// @Tags health
+// @Produce json
+// @Success 200 {object} Report "Process is alive"
+// @Failure 503 {object} Report "A liveness check failed"
+// @Router /healthz [get]
+func (handler *HealthHandler) Healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := handler.Checker.Liveness(r.Context())
+		handler.write(w, report)
+	}
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Pings the database, verifies the schema is migrated and the link service loop is alive. Fails as soon as shutdown begins
+// @Tags health
+// @Produce json
+// @Success 200 {object} Report "Ready to serve traffic"
+// @Failure 503 {object} Report "A dependency is unavailable or the server is shutting down"
+// @Router /readyz [get]
+func (handler *HealthHandler) Readyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := handler.Checker.Readiness(r.Context())
+		if report.Status != STATUS_OK {
+			handler.Logger.Warn().Interface("checks", report.Checks).Msg("Readiness check failed")
+		}
+		handler.write(w, report)
+	}
+}
+
+func (handler *HealthHandler) write(w http.ResponseWriter, report *Report) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	status := http.StatusOK
+	if report.Status != STATUS_OK {
+		status = http.StatusServiceUnavailable
+	}
+
+	res.Json(w, report, status)
+}
@@ -0,0 +1,162 @@
+package link
+
+import (
+	"errors"
+	"hash/maphash"
+	"math"
+	"sync"
+	"time"
+
+	"UrlShortenerBackend/pkg/cache"
+	"UrlShortenerBackend/pkg/hashids"
+)
+
+// RedirectTarget is what the redirect path needs to know about a link.
+type RedirectTarget struct {
+	LinkId    uint
+	Hash      string
+	Url       string
+	ExpiresAt *time.Time
+	// PasswordHash is empty unless the link is password-protected
+	PasswordHash string
+}
+
+// redirectEntry is a cached lookup. Found is false for hashes that do not
+// exist, so repeated requests for unknown hashes skip the database too.
+type redirectEntry struct {
+	Target RedirectTarget
+	Found  bool
+}
+
+// RedirectCache sits in front of the link store for GET /{hash}. It is local
+// to the process, so every instance keeps its own copy and relies on the
+// TTL to pick up changes made through another instance.
+//
+// A miss reads the store and then caches what it read. Invalidate bumps the
+// generation of the hash, and the read is only cached when the generation
+// did not change meanwhile, so a change committed during the read never
+// leaves the old link cached.
+type RedirectCache struct {
+	Store LinkStore
+	// Codes decodes sequence hashes so misses are looked up by primary key,
+	// nil in random mode
+	Codes       *hashids.Codec
+	entries     *cache.LRU[string, redirectEntry]
+	ttl         time.Duration
+	negativeTtl time.Duration
+	mutex       sync.Mutex
+	seed        maphash.Seed
+	generations [REDIRECT_CACHE_GENERATIONS]uint64
+}
+
+func NewRedirectCache(store LinkStore, codes *hashids.Codec, size int, ttl, negativeTtl time.Duration) *RedirectCache {
+	return &RedirectCache{
+		Store:       store,
+		Codes:       codes,
+		entries:     cache.NewLRU[string, redirectEntry](size),
+		ttl:         ttl,
+		negativeTtl: negativeTtl,
+		seed:        maphash.MakeSeed(),
+	}
+}
+
+// Resolve returns the redirect target of a live link, or ErrNotFound when
+// the hash is unknown.
+func (c *RedirectCache) Resolve(hash string) (*RedirectTarget, error) {
+	if cached, ok := c.entries.Get(hash); ok {
+		if !cached.Found {
+			return nil, ErrNotFound
+		}
+		target := cached.Target
+		return &target, nil
+	}
+
+	generation := c.generation(hash)
+	link, err := c.lookup(hash)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.set(hash, generation, redirectEntry{Found: false}, c.negativeTtl)
+		}
+		return nil, err
+	}
+
+	target := RedirectTarget{
+		LinkId:       link.ID,
+		Hash:         link.Hash,
+		Url:          link.Url,
+		ExpiresAt:    link.ExpiresAt,
+		PasswordHash: link.PasswordHash,
+	}
+
+	// Never keep a link cached past its expiry
+	ttl := c.ttl
+	if link.ExpiresAt != nil {
+		ttl = min(ttl, time.Until(*link.ExpiresAt))
+	}
+	c.set(hash, generation, redirectEntry{Target: target, Found: true}, ttl)
+
+	return &target, nil
+}
+
+// lookup reads a link by primary key when the hash decodes to one. Renamed
+// links and hashes stored before sequence mode fall back to the hash index.
+func (c *RedirectCache) lookup(hash string) (*Link, error) {
+	if c.Codes != nil {
+		if id, err := c.Codes.Decode(hash); err == nil && id <= math.MaxUint {
+			link, err := c.Store.GetLinkById(uint(id))
+			if err == nil && link.Hash == hash {
+				return link, nil
+			}
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+		}
+	}
+
+	return c.Store.GetLinkByHash(hash, "")
+}
+
+func (c *RedirectCache) slot(hash string) *uint64 {
+	return &c.generations[maphash.String(c.seed, hash)%REDIRECT_CACHE_GENERATIONS]
+}
+
+func (c *RedirectCache) generation(hash string) uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return *c.slot(hash)
+}
+
+// set caches entry unless hash was invalidated since generation was read.
+func (c *RedirectCache) set(hash string, generation uint64, entry redirectEntry, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if *c.slot(hash) == generation {
+		c.entries.Set(hash, entry, ttl)
+	}
+}
+
+func (c *RedirectCache) Invalidate(hashes ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, hash := range hashes {
+		*c.slot(hash)++
+	}
+	c.entries.Delete(hashes...)
+}
+
+func (c *RedirectCache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i := range c.generations {
+		c.generations[i]++
+	}
+	c.entries.Purge()
+}
+
+func (c *RedirectCache) Stats() cache.Stats {
+	return c.entries.Stats()
+}
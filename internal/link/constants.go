@@ -1,5 +1,7 @@
 package link
 
+import "time"
+
 const (
 	DEFAULT_PAGE   = 1
 	DEFAULT_LIMIT  = 10
@@ -7,4 +9,87 @@ const (
 
 	DEFAULT_LIFETIME_DAYS    = 90
 	DEFAULT_NUMBER_OF_CLICKS = 0
+	DEFAULT_EXTEND_DAYS      = 1
+
+	DEFAULT_EXPIRY_CHECK_INTERVAL = time.Hour
+
+	BATCH_MODE_ATOMIC      = "atomic"
+	BATCH_MODE_PARTIAL     = "partial"
+	DEFAULT_BATCH_MAX_SIZE = 500
+	// Body bytes a batch may use per link it is allowed to hold
+	MAX_BATCH_LINK_BYTES = 16 << 10
+
+	BULK_ACTION_DELETE  = "delete"
+	BULK_ACTION_RESTORE = "restore"
+	BULK_ACTION_EXTEND  = "extend"
+
+	BULK_STATUS_DELETED    = "deleted"
+	BULK_STATUS_RESTORED   = "restored"
+	BULK_STATUS_EXTENDED   = "extended"
+	BULK_STATUS_NOT_FOUND  = "not_found"
+	BULK_STATUS_HASH_TAKEN = "hash_taken"
+	BULK_STATUS_NO_EXPIRY  = "no_expiry"
+
+	TRANSFER_FORMAT_CSV    = "csv"
+	TRANSFER_FORMAT_JSON   = "json"
+	TRANSFER_FORMAT_NDJSON = "ndjson"
+
+	EXPORT_BATCH_SIZE       = 500
+	DEFAULT_IMPORT_MAX_ROWS = 1000
+	MAX_IMPORT_BYTES        = 32 << 20
+	IMPORT_TIMEOUT          = 5 * time.Minute
+	IMPORT_REPORT_MARGIN    = 10 * time.Second
+
+	LINK_SORT_CREATED_AT = "created_at"
+	LINK_SORT_EXPIRES_AT = "expires_at"
+	LINK_SORT_CLICKS     = "clicks"
+
+	LIST_COUNT_NONE     = "none"
+	LIST_COUNT_EXACT    = "exact"
+	LIST_COUNT_ESTIMATE = "estimate"
+
+	DELETED_EXCLUDE = "exclude"
+	DELETED_INCLUDE = "include"
+	DELETED_ONLY    = "only"
+
+	DEFAULT_CLICK_FLUSH_INTERVAL = 5 * time.Second
+	DEFAULT_CLICK_BATCH_SIZE     = 500
+	DEFAULT_CLICK_BUFFER_SIZE    = 10000
+	CLICK_FLUSH_STOP_ATTEMPTS    = 3
+	CLICK_FLUSH_RETRY_DELAY      = 500 * time.Millisecond
+
+	DEFAULT_EXPIRING_SOON_WINDOW = 24 * time.Hour
+
+	HEARTBEAT_INTERVAL = 10 * time.Second
+	HEARTBEAT_TIMEOUT  = 3 * HEARTBEAT_INTERVAL
+
+	HASH_MODE_RANDOM   = "random"
+	HASH_MODE_SEQUENCE = "sequence"
+
+	CASE_FOLD_PRESERVE = "preserve"
+	CASE_FOLD_LOWER    = "lower"
+
+	DEFAULT_VANITY_CHARSET    = "A-Za-z0-9_-"
+	DEFAULT_VANITY_MIN_LENGTH = 3
+	DEFAULT_VANITY_MAX_LENGTH = 32
+
+	DEFAULT_HASH_ALPHABET       = "1234567890abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	DEFAULT_HASH_LENGTH         = 10
+	DEFAULT_HASH_MAX_LENGTH     = 16
+	DEFAULT_HASH_GROW_THRESHOLD = 0.01
+	HASH_COLLISION_WINDOW       = 200
+	MAX_HASH_ATTEMPTS           = 5
+
+	JOB_LIFETIME    = "lifetime"
+	JOB_CLICK_FLUSH = "click_flush"
+	JOB_TRASH_PURGE = "trash_purge"
+
+	MIN_LINK_PASSWORD_LENGTH     = 4
+	MAX_LINK_PASSWORD_BYTES      = 72
+	MAX_PASSWORD_FORM_BYTES      = 4 << 10
+	PASSWORD_COOKIE_NAME         = "link_unlock"
+	PASSWORD_ATTEMPTS_CACHE_SIZE = 100000
+
+	// Invalidation counters of the redirect cache, hashes share them
+	REDIRECT_CACHE_GENERATIONS = 256
 )
@@ -0,0 +1,173 @@
+package link
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// LinkCursor marks a position in a listing: the sort key and ID of the
+// link at the edge of a page. The next page runs after it, the previous
+// page before it when Before is set. Time is the created_at or expires_at
+// of the link depending on the sort, nil for a link without expiry.
+type LinkCursor struct {
+	Field  string     `json:"f"`
+	Desc   bool       `json:"d,omitempty"`
+	Before bool       `json:"b,omitempty"`
+	Id     uint       `json:"i"`
+	Time   *time.Time `json:"t,omitempty"`
+	Clicks int64      `json:"c,omitempty"`
+}
+
+// LinkPage is one page of a cursor listing. A cursor is empty when there
+// is nothing more in its direction.
+type LinkPage struct {
+	Links      []Link
+	NextCursor string
+	PrevCursor string
+}
+
+func cursorAt(link *Link, sort LinkSort, before bool) *LinkCursor {
+	cursor := &LinkCursor{
+		Field:  sort.Field,
+		Desc:   sort.Desc,
+		Before: before,
+		Id:     link.ID,
+	}
+
+	switch sort.Field {
+	case LINK_SORT_CLICKS:
+		cursor.Clicks = link.NumberOfClicks
+	case LINK_SORT_EXPIRES_AT:
+		cursor.Time = link.ExpiresAt
+	default:
+		createdAt := link.CreatedAt
+		cursor.Time = &createdAt
+	}
+
+	return cursor
+}
+
+// Sort is the order the cursor was issued for.
+func (c *LinkCursor) Sort() LinkSort {
+	return LinkSort{Field: c.Field, Desc: c.Desc}
+}
+
+// Encode returns the opaque form handed to clients.
+func (c *LinkCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (*LinkCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var cursor LinkCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+	if _, ok := sortColumn[cursor.Field]; !ok || cursor.Id == 0 {
+		return nil, errInvalidCursor
+	}
+	if cursor.Time == nil && cursor.Field == LINK_SORT_CREATED_AT {
+		return nil, errInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// link returns a link holding the sort key of the cursor, to compare it
+// with listed links.
+func (c *LinkCursor) link() *Link {
+	link := &Link{ID: c.Id, NumberOfClicks: c.Clicks, ExpiresAt: c.Time}
+	if c.Time != nil {
+		link.CreatedAt = *c.Time
+	}
+	return link
+}
+
+// value is the sort key of the cursor as a query argument, nil for a link
+// without expiry.
+func (c *LinkCursor) value() any {
+	switch {
+	case c.Field == LINK_SORT_CLICKS:
+		return c.Clicks
+	case c.Time == nil:
+		return nil
+	}
+	return *c.Time
+}
+
+// keysetCondition selects the links after the cursor, or before it, in
+// the order of sort. Links without expiry come last in both directions.
+func keysetCondition(sort LinkSort, cursor *LinkCursor) (string, []any) {
+	column := sortColumn[sort.Field]
+
+	// Later links have greater keys in ascending order
+	op := ">"
+	if sort.Desc != cursor.Before {
+		op = "<"
+	}
+
+	value := cursor.value()
+	if column != "expires_at" {
+		return "(" + column + " " + op + " ? OR (" + column + " = ? AND id " + op + " ?))",
+			[]any{value, value, cursor.Id}
+	}
+
+	switch {
+	case !cursor.Before && value != nil:
+		return "(expires_at " + op + " ? OR (expires_at = ? AND id " + op + " ?) OR expires_at IS NULL)",
+			[]any{value, value, cursor.Id}
+	case !cursor.Before:
+		return "(expires_at IS NULL AND id " + op + " ?)", []any{cursor.Id}
+	case value != nil:
+		return "(expires_at " + op + " ? OR (expires_at = ? AND id " + op + " ?))",
+			[]any{value, value, cursor.Id}
+	}
+	return "(expires_at IS NOT NULL OR id " + op + " ?)", []any{cursor.Id}
+}
+
+// newLinkPage trims the extra link a store returns to tell whether there
+// is more in the direction of the cursor, and sets the cursors. links come
+// in listing order, the extra one first when paging backwards.
+func newLinkPage(links []Link, sort LinkSort, cursor *LinkCursor, limit int) *LinkPage {
+	backwards := cursor != nil && cursor.Before
+	more := len(links) > limit
+	if more && backwards {
+		links = links[len(links)-limit:]
+	} else if more {
+		links = links[:limit]
+	}
+
+	page := &LinkPage{Links: slices.Clip(links)}
+	if len(links) == 0 {
+		// Nothing left in this direction, offer the way back
+		if cursor != nil {
+			back := *cursor
+			back.Before = !back.Before
+			if backwards {
+				page.NextCursor = back.Encode()
+			} else {
+				page.PrevCursor = back.Encode()
+			}
+		}
+		return page
+	}
+
+	if more || backwards {
+		page.NextCursor = cursorAt(&links[len(links)-1], sort, false).Encode()
+	}
+	if (more && backwards) || (cursor != nil && !backwards) {
+		page.PrevCursor = cursorAt(&links[0], sort, true).Encode()
+	}
+
+	return page
+}
@@ -0,0 +1,207 @@
+package link
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"UrlShortenerBackend/internal/click"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	expiresAt := time.Date(2025, 4, 23, 12, 30, 0, 123456789, time.UTC)
+	link := &Link{ID: 42, CreatedAt: expiresAt.Add(-time.Hour), ExpiresAt: &expiresAt, NumberOfClicks: 7}
+
+	tests := []struct {
+		name   string
+		sort   LinkSort
+		before bool
+		link   *Link
+	}{
+		{"created at", LinkSort{Field: LINK_SORT_CREATED_AT, Desc: true}, false, link},
+		{"expires at", LinkSort{Field: LINK_SORT_EXPIRES_AT}, true, link},
+		{"no expiry", LinkSort{Field: LINK_SORT_EXPIRES_AT, Desc: true}, false, &Link{ID: 3, CreatedAt: expiresAt}},
+		{"clicks", LinkSort{Field: LINK_SORT_CLICKS, Desc: true}, true, link},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := cursorAt(tt.link, tt.sort, tt.before)
+
+			decoded, err := DecodeCursor(cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+			if decoded.Sort() != tt.sort || decoded.Before != tt.before || decoded.Id != tt.link.ID || decoded.Clicks != cursor.Clicks {
+				t.Errorf("DecodeCursor() = %+v, want %+v", decoded, cursor)
+			}
+			if (decoded.Time == nil) != (cursor.Time == nil) || (decoded.Time != nil && !decoded.Time.Equal(*cursor.Time)) {
+				t.Errorf("time = %v, want %v", decoded.Time, cursor.Time)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	valid := cursorAt(&Link{ID: 1, CreatedAt: time.Now()}, LinkSort{Field: LINK_SORT_CREATED_AT}, false)
+
+	unknownField := *valid
+	unknownField.Field = "password_hash"
+	noId := *valid
+	noId.Id = 0
+	noTime := *valid
+	noTime.Time = nil
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "!!!"},
+		{"not json", "bm90IGpzb24"},
+		{"unknown field", unknownField.Encode()},
+		{"no id", noId.Encode()},
+		{"created at without time", noTime.Encode()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.value); err == nil {
+				t.Errorf("DecodeCursor(%q) accepted an invalid cursor", tt.value)
+			}
+		})
+	}
+}
+
+// TestKeysetPaging walks every listing order forwards to its end and back
+// to its start, and expects the links of the numbered listing in both
+// directions.
+func TestKeysetPaging(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		now := time.Now().UTC().Truncate(time.Second)
+		soon := now.Add(time.Hour)
+		later := now.Add(2 * time.Hour)
+
+		// Ties on every sort key and links without expiry, so the ID has to
+		// break ties and NULL has to stay last
+		links := []*Link{
+			{Hash: "k1", ExpiresAt: &later},
+			{Hash: "k2"},
+			{Hash: "k3", ExpiresAt: &soon},
+			{Hash: "k4", ExpiresAt: &later},
+			{Hash: "k5"},
+			{Hash: "k6", ExpiresAt: &soon},
+			{Hash: "k7"},
+		}
+		for _, link := range links {
+			link.Url = "https://example.org/" + link.Hash
+			link.UserId = "alice"
+			mustCreate(t, store, link)
+		}
+		mustCreate(t, store, &Link{Hash: "other", Url: "https://example.org/", UserId: "bob"})
+
+		events := []*click.ClickEvent{}
+		for i, link := range links {
+			for range i % 3 {
+				events = append(events, &click.ClickEvent{LinkId: link.ID, Hash: link.Hash, CreatedAt: now})
+			}
+		}
+		if err := store.RecordClicks(events); err != nil {
+			t.Fatal(err)
+		}
+
+		service := &LinkService{Repository: store}
+		filter := LinkFilter{UserId: "alice"}
+
+		sorts := []LinkSort{
+			{Field: LINK_SORT_CREATED_AT},
+			{Field: LINK_SORT_CREATED_AT, Desc: true},
+			{Field: LINK_SORT_EXPIRES_AT},
+			{Field: LINK_SORT_EXPIRES_AT, Desc: true},
+			{Field: LINK_SORT_CLICKS},
+			{Field: LINK_SORT_CLICKS, Desc: true},
+		}
+
+		for _, sort := range sorts {
+			name := sort.Field
+			if sort.Desc {
+				name += " desc"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				all, err := store.GetAllLinks(filter, sort, 1, 100)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := linkHashes(all.Links)
+				if sort.Field == LINK_SORT_EXPIRES_AT && !slices.Equal(want[4:], []string{"k2", "k5", "k7"}) &&
+					!slices.Equal(want[4:], []string{"k7", "k5", "k2"}) {
+					t.Fatalf("links without expiry are not last in %v", want)
+				}
+
+				forwards := []string{}
+				page, err := service.LinkPage(filter, sort, nil, 3)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if page.PrevCursor != "" {
+					t.Error("the first page has a previous cursor")
+				}
+				for {
+					forwards = append(forwards, linkHashes(page.Links)...)
+					if page.NextCursor == "" {
+						break
+					}
+					page = nextPage(t, service, filter, page.NextCursor)
+				}
+				if !slices.Equal(forwards, want) {
+					t.Errorf("forwards = %v, want %v", forwards, want)
+				}
+
+				backwards := linkHashes(page.Links)
+				for page.PrevCursor != "" {
+					page = nextPage(t, service, filter, page.PrevCursor)
+					backwards = append(linkHashes(page.Links), backwards...)
+				}
+				if !slices.Equal(backwards, want) {
+					t.Errorf("backwards = %v, want %v", backwards, want)
+				}
+			})
+		}
+	})
+}
+
+func nextPage(t *testing.T, service *LinkService, filter LinkFilter, value string) *LinkPage {
+	t.Helper()
+
+	cursor, err := DecodeCursor(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := service.LinkPage(filter, cursor.Sort(), cursor, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Links) == 0 {
+		t.Fatal("a cursor led to an empty page")
+	}
+	return page
+}
+
+func TestEmptyPageOffersTheWayBack(t *testing.T) {
+	sort := LinkSort{Field: LINK_SORT_CREATED_AT, Desc: true}
+	cursor := cursorAt(&Link{ID: 5, CreatedAt: time.Now()}, sort, false)
+
+	page := newLinkPage([]Link{}, sort, cursor, 3)
+	if page.NextCursor != "" || page.PrevCursor == "" {
+		t.Fatalf("empty page cursors = %q, %q", page.NextCursor, page.PrevCursor)
+	}
+
+	back, err := DecodeCursor(page.PrevCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !back.Before || back.Id != cursor.Id {
+		t.Errorf("way back = %+v, want the cursor reversed", back)
+	}
+}
@@ -0,0 +1,52 @@
+package link
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Errors returned by every LinkStore. They wrap the underlying database
+// error when there is one, so both can be matched with errors.Is.
+var (
+	ErrNotFound     = errors.New("link not found")
+	ErrForbidden    = errors.New("link not found or user does not have permission")
+	ErrHashTaken    = errors.New("hash already exists")
+	ErrUserNotFound = errors.New("user not found")
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// ErrHashInTrash is the ErrHashTaken of a hash held by a deleted link that
+// can still be restored. The hash is free again once the link is purged.
+var ErrHashInTrash = fmt.Errorf("%w by a deleted link", ErrHashTaken)
+
+// wrapError attaches a sentinel to a database error.
+func wrapError(sentinel, err error) error {
+	if err == nil {
+		return sentinel
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
+
+// translateError maps the GORM errors the stores care about onto the
+// sentinels and wraps anything else with context.
+func translateError(err error, context string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return wrapError(ErrNotFound, err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return wrapError(ErrHashTaken, err)
+	}
+	return fmt.Errorf("%s: %w", context, err)
+}
+
+// ErrBatchAborted marks links of an atomic batch that were rolled back
+// because another link of the batch failed.
+var ErrBatchAborted = errors.New("batch rolled back")
+
+// ErrBulkTooLarge is returned when a bulk operation selects more links than
+// it may change at once.
+var ErrBulkTooLarge = errors.New("too many links selected")
@@ -0,0 +1,131 @@
+package link
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LinkFilter selects links of one user. Domain matches the host of the
+// destination and its subdomains, HashPrefix is case-sensitive and Search
+// looks for a case-insensitive substring of the URL or the title. Ranges
+// include their start and exclude their end, links without expiry are left
+// out by an expiry range. Deleted works like in AdminLinkFilter.
+type LinkFilter struct {
+	UserId      string
+	Hashes      []string
+	HashPrefix  string
+	Tag         string
+	Domain      string
+	Search      string
+	MinClicks   int64
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	ExpiresFrom *time.Time
+	ExpiresTo   *time.Time
+	Deleted     string
+}
+
+// LinkSort orders a listing by one of the LINK_SORT_* fields, the ID
+// breaking ties in the same direction. Links without expiry come last when
+// sorting by expiry, whatever the direction.
+type LinkSort struct {
+	Field string
+	Desc  bool
+}
+
+// sortColumn maps the sort fields onto their column.
+var sortColumn = map[string]string{
+	LINK_SORT_CREATED_AT: "created_at",
+	LINK_SORT_EXPIRES_AT: "expires_at",
+	LINK_SORT_CLICKS:     "number_of_clicks",
+}
+
+// matches applies the whole filter to link, for stores without a query
+// language.
+func (f *LinkFilter) matches(link *Link) bool {
+	switch f.Deleted {
+	case DELETED_INCLUDE:
+	case DELETED_ONLY:
+		if isLive(link) {
+			return false
+		}
+	default:
+		if !isLive(link) {
+			return false
+		}
+	}
+
+	if link.UserId != f.UserId {
+		return false
+	}
+	if len(f.Hashes) > 0 && !slices.Contains(f.Hashes, link.Hash) {
+		return false
+	}
+	if !strings.HasPrefix(link.Hash, f.HashPrefix) {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(link.Tags, f.Tag) {
+		return false
+	}
+	if link.NumberOfClicks < f.MinClicks {
+		return false
+	}
+	if !inRange(&link.CreatedAt, f.CreatedFrom, f.CreatedTo) {
+		return false
+	}
+	if (f.ExpiresFrom != nil || f.ExpiresTo != nil) && !inRange(link.ExpiresAt, f.ExpiresFrom, f.ExpiresTo) {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(link.Url), search) && !strings.Contains(strings.ToLower(link.Title), search) {
+			return false
+		}
+	}
+
+	return f.matchesDomain(link.Url)
+}
+
+// matchesDomain reports whether the destination host is Domain or one of
+// its subdomains.
+func (f *LinkFilter) matchesDomain(rawUrl string) bool {
+	if f.Domain == "" {
+		return true
+	}
+
+	host := urlHost(rawUrl)
+	domain := strings.TrimSuffix(strings.ToLower(f.Domain), ".")
+
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// urlHost returns the lowercase host of a URL without port nor trailing
+// dot, empty when the URL cannot be parsed.
+func urlHost(rawUrl string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+}
+
+func inRange(t *time.Time, from, to *time.Time) bool {
+	if t == nil {
+		return false
+	}
+	if from != nil && t.Before(*from) {
+		return false
+	}
+	if to != nil && !t.Before(*to) {
+		return false
+	}
+	return true
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, used with ESCAPE '\'.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
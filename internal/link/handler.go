@@ -1,40 +1,73 @@
 package link
 
 import (
+	"bufio"
+	"context"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/internal/urlpolicy"
+	"UrlShortenerBackend/pkg/metrics"
+	"UrlShortenerBackend/pkg/middleware"
+	"UrlShortenerBackend/pkg/mux"
+	"UrlShortenerBackend/pkg/problem"
 	"UrlShortenerBackend/pkg/req"
 	"UrlShortenerBackend/pkg/res"
 
 	"github.com/rs/zerolog"
-	"gorm.io/gorm"
 )
 
 type LinkHandlerDeps struct {
-	LinkRepository *LinkRepository
-	Config         *configs.Config
-	Logger         *zerolog.Logger
+	LinkStore   LinkStore
+	ClickStore  click.ClickStore
+	LinkService *LinkService
+	ClientIps   *click.ClientIpResolver
+	Config      *configs.Config
+	Logger      *zerolog.Logger
 }
 
 type LinkHandler struct {
-	LinkRepository *LinkRepository
-	Logger         *zerolog.Logger
+	LinkStore   LinkStore
+	ClickStore  click.ClickStore
+	LinkService *LinkService
+	ClientIps   *click.ClientIpResolver
+	Config      *configs.Config
+	Logger      *zerolog.Logger
 }
 
-func NewLinkHandler(router *http.ServeMux, deps *LinkHandlerDeps) {
+func NewLinkHandler(router *mux.Router, deps *LinkHandlerDeps) {
 	handler := &LinkHandler{
-		LinkRepository: deps.LinkRepository,
-		Logger:         deps.Logger,
+		LinkStore:   deps.LinkStore,
+		ClickStore:  deps.ClickStore,
+		LinkService: deps.LinkService,
+		ClientIps:   deps.ClientIps,
+		Config:      deps.Config,
+		Logger:      deps.Logger,
 	}
 
 	router.HandleFunc("GET /{hash}", handler.Redirect())
+	router.HandleFunc("POST /{hash}", handler.UnlockLink())
 
 	router.HandleFunc("GET /api/v1/links", handler.GetLink())
 	router.HandleFunc("GET /api/v1/links/all", handler.GetAllLinks())
+	router.HandleFunc("GET /api/v1/links/clicks", handler.GetClicks())
+	router.HandleFunc("GET /api/v1/links/export", handler.ExportLinks())
+	router.HandleFunc("GET /api/v1/links/trash", handler.GetTrash())
+	router.HandleFunc("GET /api/v1/links/{hash}/stats", handler.GetStats())
 	router.HandleFunc("POST /api/v1/links", handler.CreateLink())
+	router.HandleFunc("POST /api/v1/links/batch", handler.CreateLinks())
+	router.HandleFunc("POST /api/v1/links/import", handler.ImportLinks())
+	router.HandleFunc("POST /api/v1/links/bulk/delete", handler.BulkDelete())
+	router.HandleFunc("POST /api/v1/links/bulk/restore", handler.BulkRestore())
+	router.HandleFunc("POST /api/v1/links/bulk/extend", handler.BulkExtend())
+	router.HandleFunc("POST /api/v1/links/{hash}/restore", handler.RestoreLink())
+	router.HandleFunc("PATCH /api/v1/links/{hash}", handler.UpdateLink())
 	router.HandleFunc("DELETE /api/v1/links", handler.DeleteLink())
 
 	router.HandleFunc("POST /api/v1/links/add-days", handler.AddDays())
@@ -42,116 +75,1388 @@ func NewLinkHandler(router *http.ServeMux, deps *LinkHandlerDeps) {
 
 // Redirect godoc
 // @Summary Redirect to original URL
-// @Description Redirects to the original URL using the provided hash
+// @Description Redirects to the original URL using the provided hash. Password-protected links answer with an HTML password form instead, unless the visitor unlocked the link recently
 // @Tags links
 // @Produce html
 // @Param hash path string true "Hash of the shortened link"
+// @Success 200 {string} string "Password form of a protected link"
 // @Success 302 {string} string "Redirect to the original URL"
-// @Failure 400 {string} string "Hash parameter is missing"
-// @Failure 404 {string} string "Link not found"
+// @Failure 400 {object} problem.Problem "Hash parameter is missing"
+// @Failure 404 {object} problem.Problem "Link not found"
+// @Failure 410 {object} problem.Problem "Link has expired"
 // @Router /{hash} [get]
 func (handler *LinkHandler) Redirect() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		hash := r.PathValue("hash")
+		target, ok := handler.resolveTarget(w, r)
+		if !ok {
+			return
+		}
+
+		if target.PasswordHash != "" && !handler.LinkService.Passwords.Unlocked(r, target) {
+			metrics.Redirects.WithLabelValues(metrics.REDIRECT_LOCKED).Inc()
+			handler.Logger.Info().Str("hash", target.Hash).Msg("Link is password-protected, asking for the password")
+			if err := writePasswordForm(w, r, http.StatusOK, ""); err != nil {
+				handler.Logger.Error().Err(err).Str("hash", target.Hash).Msg("Failed to write password form")
+			}
+			return
+		}
+
+		handler.follow(w, r, target, http.StatusFound)
+	}
+}
+
+// UnlockLink godoc
+// @Summary Unlock a password-protected link
+// @Description Checks the password submitted by the form of a protected link. The right password sets a short-lived cookie so later visits skip the form, and redirects to the original URL. Password attempts are limited per link and client address, and per link
+// @Tags links
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param hash path string true "Hash of the shortened link"
+// @Param password formData string true "Password of the link"
+// @Success 303 {string} string "Redirect to the original URL"
+// @Failure 400 {string} string "Password form, the submission could not be read"
+// @Failure 401 {string} string "Password form, the password is wrong"
+// @Failure 404 {object} problem.Problem "Link not found"
+// @Failure 410 {object} problem.Problem "Link has expired"
+// @Failure 429 {string} string "Password form, too many password attempts"
+// @Router /{hash} [post]
+func (handler *LinkHandler) UnlockLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target, ok := handler.resolveTarget(w, r)
+		if !ok {
+			return
+		}
+
+		if target.PasswordHash == "" {
+			handler.follow(w, r, target, http.StatusSeeOther)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, MAX_PASSWORD_FORM_BYTES)
+		passwords := handler.LinkService.Passwords
+		ip := handler.ClientIps.ClientIp(r)
+
+		status, message := http.StatusOK, ""
+		if retryAfter := passwords.Attempt(target.LinkId, ip); retryAfter > 0 {
+			handler.Logger.Warn().
+				Str("hash", target.Hash).
+				Dur("retry_after", retryAfter).
+				Msg("Too many link password attempts")
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second).Seconds())))
+			status, message = http.StatusTooManyRequests, "Too many wrong passwords, try again later."
+		} else if err := r.ParseForm(); err != nil {
+			passwords.Release(target.LinkId, ip)
+			handler.Logger.Error().Err(err).Str("hash", target.Hash).Msg("Failed to read password form")
+			status, message = http.StatusBadRequest, "The form could not be read, try again."
+		} else if !passwords.CheckPassword(target, r.PostForm.Get("password")) {
+			handler.Logger.Warn().Str("hash", target.Hash).Msg("Wrong link password")
+			status, message = http.StatusUnauthorized, "Wrong password."
+		}
+
+		if message != "" {
+			if err := writePasswordForm(w, r, status, message); err != nil {
+				handler.Logger.Error().Err(err).Str("hash", target.Hash).Msg("Failed to write password form")
+			}
+			return
+		}
+
+		passwords.Succeeded(target.LinkId, ip)
+		passwords.Unlock(w, r, target, handler.ClientIps.Secure(r))
+
+		handler.Logger.Info().Str("hash", target.Hash).Msg("Link unlocked")
+
+		handler.follow(w, r, target, http.StatusSeeOther)
+	}
+}
+
+// resolveTarget finds the live link of the hash in the path, answering with
+// a problem when there is none.
+func (handler *LinkHandler) resolveTarget(w http.ResponseWriter, r *http.Request) (*RedirectTarget, bool) {
+	hash := r.PathValue("hash")
+	if hash == "" {
+		handler.Logger.Error().Msg("Hash parameter is missing")
+		problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Hash parameter is required")
+		return nil, false
+	}
+
+	target, err := handler.LinkService.Cache.Resolve(hash)
+	if errors.Is(err, ErrNotFound) && handler.LinkService.Policy.FoldsCase() && strings.ToLower(hash) != hash {
+		target, err = handler.LinkService.Cache.Resolve(strings.ToLower(hash))
+	}
+	if err != nil {
+		metrics.Redirects.WithLabelValues(metrics.REDIRECT_MISS).Inc()
+		handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to find link by hash")
+		problem.Respond(w, r, http.StatusNotFound, problem.CODE_NOT_FOUND, "Link not found")
+		return nil, false
+	}
+
+	if target.ExpiresAt != nil && !target.ExpiresAt.After(time.Now()) {
+		metrics.Redirects.WithLabelValues(metrics.REDIRECT_EXPIRED).Inc()
+		handler.Logger.Info().Str("hash", hash).Msg("Link has expired")
+		problem.Respond(w, r, http.StatusGone, problem.CODE_LINK_EXPIRED, "Link has expired")
+		return nil, false
+	}
+
+	return target, true
+}
+
+// follow records the click and redirects to the original URL.
+func (handler *LinkHandler) follow(w http.ResponseWriter, r *http.Request, target *RedirectTarget, status int) {
+	metrics.Redirects.WithLabelValues(metrics.REDIRECT_HIT).Inc()
+
+	event := click.NewClickEvent(target.LinkId, target.Hash, r, handler.ClientIps.ClientIp(r), handler.Config.Clicks.IpHashSalt)
+	handler.LinkService.RecordClick(event)
+
+	handler.Logger.Info().
+		Str("hash", target.Hash).
+		Str("url", target.Url).
+		Msg("Redirecting to URL")
+
+	http.Redirect(w, r, target.Url, status)
+}
+
+// GetLink godoc
+// @Summary Get link details
+// @Description Get details of a specific shortened link by hash
+// @Tags links
+// @Produce json
+// @Security ApiKeyAuth
+// @Param hash query string true "Hash of the shortened link"
+// @Success 200 {object} Link "Link details"
+// @Failure 400 {object} problem.Problem "Missing parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 403 {object} problem.Problem "Link not found or user does not have access"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links [get]
+func (handler *LinkHandler) GetLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		hash := r.URL.Query().Get("hash")
+		if hash == "" {
+			handler.Logger.Error().Msg("Hash is required")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Hash is required")
+			return
+		}
+
+		link, err := handler.LinkStore.GetLinkByHash(hash, userId)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				handler.Logger.Error().
+					Err(err).
+					Str("hash", hash).
+					Str("user_id", userId).
+					Msg("Link not found or user does not have access")
+				problem.Respond(w, r, http.StatusForbidden, problem.CODE_FORBIDDEN, "Link not found or user does not have access")
+				return
+			}
+
+			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to find link")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve link")
+			return
+		}
+
+		handler.Logger.Info().
+			Str("hash", hash).
+			Str("url", link.Url).
+			Str("user_id", link.UserId).
+			Msg("Link details retrieved successfully")
+
+		res.Json(w, link, http.StatusOK)
+	}
+}
+
+// GetAllLinks godoc
+// @Summary Get all user links
+// @Description Get a list of the links belonging to the authenticated user, optionally filtered and sorted. Filters combine with AND, ranges include their start and exclude their end.
+// @Description Pages are numbered by default. With the cursor parameter the listing is read with opaque cursors instead, empty for the first page, and the response is a GetLinksPageResponse: pages stay stable while links are created or deleted and deep pages cost no more than the first one. A cursor carries its order, sort and order may be left out with it
+// @Tags links
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Param domain query string false "Destination domain, subdomains included"
+// @Param hash_prefix query string false "Case-sensitive prefix of the hash"
+// @Param tag query string false "Tag the link carries"
+// @Param q query string false "Case-insensitive text searched in the URL and the title"
+// @Param min_clicks query int false "Minimum number of clicks"
+// @Param created_from query string false "Created at or after, RFC 3339 or YYYY-MM-DD"
+// @Param created_to query string false "Created before, RFC 3339 or YYYY-MM-DD"
+// @Param expires_from query string false "Expiring at or after, RFC 3339 or YYYY-MM-DD"
+// @Param expires_to query string false "Expiring before, RFC 3339 or YYYY-MM-DD"
+// @Param sort query string false "Sort key, links without expiry come last" Enums(created_at, expires_at, clicks) default(created_at)
+// @Param order query string false "Sort direction" Enums(asc, desc) default(desc)
+// @Param cursor query string false "Cursor mode, next_cursor or prev_cursor of a previous page, empty for the first page"
+// @Param count query string false "Cursor mode only, whether to count the matching links, estimate serves a count cached for a short while" Enums(none, exact, estimate) default(none)
+// @Success 200 {object} GetAllLinksResponse "List of links, GetLinksPageResponse in cursor mode"
+// @Failure 400 {object} problem.Problem "Invalid parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/all [get]
+func (handler *LinkHandler) GetAllLinks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		page := 1
+		limit := 10
+
+		if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+			if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+				page = p
+			}
+		}
+
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+				limit = l
+			}
+		}
+
+		filter, sort, err := parseListQuery(r, userId)
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Invalid listing parameters")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, err.Error())
+			return
+		}
+
+		// An empty first page already tells a user without links, so cursor
+		// pages skip the existence check
+		if r.URL.Query().Has("cursor") {
+			handler.linkPage(w, r, userId, *filter, *sort, limit)
+			return
+		}
+
+		exists, err := handler.LinkStore.CheckUserExists(userId)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Failed to check user existence")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to check user existence")
+			return
+		}
+
+		if !exists {
+			handler.Logger.Info().Str("user_id", userId).Msg("User ID does not exist, returning empty result")
+			emptyResponse := GetAllLinksResponse{
+				Links:      []Link{},
+				TotalPages: 0,
+				TotalLinks: 0,
+				Page:       page,
+				Limit:      limit,
+			}
+			res.Json(w, emptyResponse, http.StatusOK)
+			return
+		}
+
+		result, err := handler.LinkStore.GetAllLinks(*filter, *sort, page, limit)
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Failed to get links")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve links")
+			return
+		}
+
+		handler.Logger.Info().
+			Str("user_id", userId).
+			Int("total_links", int(result.TotalLinks)).
+			Int("page", result.Page).
+			Int("total_pages", result.TotalPages).
+			Msg("Successfully retrieved user links")
+
+		response := GetAllLinksResponse{
+			Links:      result.Links,
+			TotalPages: result.TotalPages,
+			TotalLinks: result.TotalLinks,
+			Page:       result.Page,
+			Limit:      result.Limit,
+		}
+
+		res.Json(w, response, http.StatusOK)
+	}
+}
+
+// linkPage answers GetAllLinks in cursor mode.
+func (handler *LinkHandler) linkPage(w http.ResponseWriter, r *http.Request, userId string, filter LinkFilter, sort LinkSort, limit int) {
+	values := r.URL.Query()
+
+	var cursor *LinkCursor
+	if value := values.Get("cursor"); value != "" {
+		decoded, err := DecodeCursor(value)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Invalid listing cursor")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Invalid cursor")
+			return
+		}
+
+		if (values.Has("sort") || values.Has("order")) && decoded.Sort() != sort {
+			handler.Logger.Error().Str("user_id", userId).Msg("Listing cursor issued for another order")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "The cursor was issued for another sort or order")
+			return
+		}
+
+		cursor = decoded
+		sort = decoded.Sort()
+	}
+
+	count := values.Get("count")
+	switch count {
+	case "":
+		count = LIST_COUNT_NONE
+	case LIST_COUNT_NONE, LIST_COUNT_EXACT, LIST_COUNT_ESTIMATE:
+	default:
+		handler.Logger.Error().Str("count", count).Msg("Invalid listing count")
+		problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Invalid count, expected none, exact or estimate")
+		return
+	}
+
+	page, err := handler.LinkService.LinkPage(filter, sort, cursor, limit)
+	if err != nil {
+		handler.Logger.Error().Err(err).Msg("Failed to get links")
+		problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve links")
+		return
+	}
+
+	response := GetLinksPageResponse{
+		Links:      page.Links,
+		Limit:      limit,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+
+	if count != LIST_COUNT_NONE {
+		var total int64
+		if count == LIST_COUNT_ESTIMATE {
+			total, err = handler.LinkService.EstimateLinkCount(filter)
+		} else {
+			total, err = handler.LinkStore.CountUserLinks(filter)
+		}
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Failed to count links")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to count links")
+			return
+		}
+		response.TotalLinks = &total
+		response.TotalEstimated = count == LIST_COUNT_ESTIMATE
+	}
+
+	handler.Logger.Info().
+		Str("user_id", userId).
+		Int("links", len(page.Links)).
+		Bool("has_next", page.NextCursor != "").
+		Bool("has_prev", page.PrevCursor != "").
+		Msg("Successfully retrieved user links page")
+
+	res.Json(w, response, http.StatusOK)
+}
+
+// GetClicks godoc
+// @Summary Get link click events
+// @Description Get the individual click events recorded for a link, newest first
+// @Tags links
+// @Produce json
+// @Security ApiKeyAuth
+// @Param hash query string true "Hash of the shortened link"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(50)
+// @Success 200 {object} GetClicksResponse "List of click events"
+// @Failure 400 {object} problem.Problem "Missing parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 403 {object} problem.Problem "Link not found or user does not have access"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/clicks [get]
+func (handler *LinkHandler) GetClicks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		hash := r.URL.Query().Get("hash")
 		if hash == "" {
-			handler.Logger.Error().Msg("Hash parameter is missing")
-			http.Error(w, "Hash parameter is required", http.StatusBadRequest)
+			handler.Logger.Error().Msg("Hash is required")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Hash is required")
+			return
+		}
+
+		page := click.DEFAULT_PAGE
+		limit := click.DEFAULT_LIMIT
+
+		if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+			if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+				page = p
+			}
+		}
+
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+				limit = l
+			}
+		}
+
+		link, err := handler.LinkStore.GetLinkByHash(hash, userId)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				handler.Logger.Error().
+					Err(err).
+					Str("hash", hash).
+					Str("user_id", userId).
+					Msg("Link not found or user does not have access")
+				problem.Respond(w, r, http.StatusForbidden, problem.CODE_FORBIDDEN, "Link not found or user does not have access")
+				return
+			}
+
+			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to find link")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve link")
+			return
+		}
+
+		result, err := handler.ClickStore.GetByLinkId(link.ID, page, limit)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to get click events")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve click events")
+			return
+		}
+
+		handler.Logger.Info().
+			Str("hash", hash).
+			Str("user_id", userId).
+			Int64("total_events", result.TotalEvents).
+			Msg("Click events retrieved successfully")
+
+		response := GetClicksResponse{
+			Events:      result.Events,
+			TotalPages:  result.TotalPages,
+			TotalEvents: result.TotalEvents,
+			Page:        result.Page,
+			Limit:       result.Limit,
+		}
+
+		res.Json(w, response, http.StatusOK)
+	}
+}
+
+// ExportLinks godoc
+// @Summary Export links
+// @Description Streams every live link of the authenticated user as CSV, a JSON array or newline-delimited JSON. The output can be sent back to the import endpoint
+// @Tags links
+// @Produce text/csv,json,application/x-ndjson
+// @Security ApiKeyAuth
+// @Param format query string false "Output format" Enums(csv, json, ndjson) default(csv)
+// @Success 200 {array} LinkExportRow "Links of the user"
+// @Failure 400 {object} problem.Problem "Unknown format"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Router /api/v1/links/export [get]
+func (handler *LinkHandler) ExportLinks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		format, err := transferFormat(r.URL.Query().Get("format"), "")
+		if err != nil {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", transferContentType(format))
+		w.Header().Set("Content-Disposition", `attachment; filename="links.`+format+`"`)
+		w.WriteHeader(http.StatusOK)
+
+		// Nothing above holds the whole export: batches are written and
+		// flushed as they are read, and each one gets a fresh write deadline
+		writer := bufio.NewWriter(w)
+		controller := http.NewResponseController(w)
+
+		encoder, err := newLinkEncoder(format, writer)
+		exported := 0
+		if err == nil {
+			err = handler.LinkStore.StreamLinks(LinkFilter{UserId: userId}, EXPORT_BATCH_SIZE, func(links []Link) error {
+				if err := controller.SetWriteDeadline(time.Now().Add(handler.Config.HTTPServer.Timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+					return err
+				}
+
+				for i := range links {
+					if err := encoder.Encode(newExportRow(&links[i])); err != nil {
+						return err
+					}
+				}
+				exported += len(links)
+
+				if err := encoder.Flush(); err != nil {
+					return err
+				}
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+				if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+					return err
+				}
+				return nil
+			})
+		}
+		if err == nil {
+			err = encoder.Close()
+		}
+		if err == nil {
+			err = writer.Flush()
+		}
+		if err != nil {
+			// The status is sent already, the truncated body is all the
+			// client gets to see
+			handler.Logger.Error().
+				Err(err).
+				Str("user_id", userId).
+				Str("format", format).
+				Int("exported", exported).
+				Msg("Export aborted")
+			return
+		}
+
+		handler.Logger.Info().
+			Str("user_id", userId).
+			Str("format", format).
+			Int("exported", exported).
+			Msg("Links exported")
+	}
+}
+
+// GetStats godoc
+// @Summary Get link analytics
+// @Description Get clicks over time bucketed by hour, day or week, together with top referrers, top user-agent families and unique visitors
+// @Tags links
+// @Produce json
+// @Security ApiKeyAuth
+// @Param hash path string true "Hash of the shortened link"
+// @Param interval query string false "Bucket size" Enums(hour, day, week) default(day)
+// @Param from query string false "Range start, RFC 3339 or YYYY-MM-DD (defaults to 7 days before to)"
+// @Param to query string false "Range end, RFC 3339 or YYYY-MM-DD (defaults to now)"
+// @Param top query int false "Number of top referrers and user agents" default(10)
+// @Success 200 {object} LinkStatsResponse "Link analytics"
+// @Failure 400 {object} problem.Problem "Invalid parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 403 {object} problem.Problem "Link not found or user does not have access"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/{hash}/stats [get]
+func (handler *LinkHandler) GetStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		hash := r.PathValue("hash")
+
+		query, err := parseStatsQuery(r)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Invalid stats parameters")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, err.Error())
+			return
+		}
+
+		link, err := handler.LinkStore.GetLinkByHash(hash, userId)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				handler.Logger.Error().
+					Err(err).
+					Str("hash", hash).
+					Str("user_id", userId).
+					Msg("Link not found or user does not have access")
+				problem.Respond(w, r, http.StatusForbidden, problem.CODE_FORBIDDEN, "Link not found or user does not have access")
+				return
+			}
+
+			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to find link")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve link")
+			return
+		}
+
+		query.LinkId = link.ID
+		stats, err := handler.ClickStore.GetStats(*query)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to get link stats")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve link stats")
+			return
+		}
+
+		handler.Logger.Info().
+			Str("hash", hash).
+			Str("user_id", userId).
+			Str("interval", string(stats.Interval)).
+			Int64("total_clicks", stats.TotalClicks).
+			Msg("Link stats retrieved successfully")
+
+		response := LinkStatsResponse{
+			Hash:           link.Hash,
+			Interval:       string(stats.Interval),
+			From:           stats.From,
+			To:             stats.To,
+			TotalClicks:    stats.TotalClicks,
+			UniqueVisitors: stats.UniqueVisitors,
+			Buckets:        stats.Buckets,
+			TopReferrers:   stats.TopReferrers,
+			TopUserAgents:  stats.TopUserAgents,
+		}
+
+		res.Json(w, response, http.StatusOK)
+	}
+}
+
+// CreateLink godoc
+// @Summary Create a new shortened link
+// @Description Creates a new shortened link
+// @Tags links
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body LinkCreateRequest true "Data for creating a link"
+// @Success 201 {object} Link "Created link"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 409 {object} problem.Problem "Hash already exists"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links [post]
+func (handler *LinkHandler) CreateLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		payload, err := req.HandleBody[LinkCreateRequest](&w, r)
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Failed to process create link request")
+			return
+		}
+
+		link, p := handler.newLink(r, payload, userId)
+		if p != nil {
+			problem.Write(w, r, p)
+			return
+		}
+
+		createdLink, err := handler.LinkService.CreateLink(link)
+		if err != nil {
+			problem.Write(w, r, handler.createProblem(link, err))
+			return
+		}
+
+		handler.LinkService.Cache.Invalidate(createdLink.Hash)
+
+		handler.Logger.Info().
+			Str("url", createdLink.Url).
+			Str("hash", createdLink.Hash).
+			Str("user_id", createdLink.UserId).
+			Time("expires_at", *createdLink.ExpiresAt).
+			Msg("Link created successfully")
+
+		res.Json(w, createdLink, http.StatusCreated)
+	}
+}
+
+// CreateLinks godoc
+// @Summary Create shortened links in bulk
+// @Description Creates every link of the array. In atomic mode (default) the links are inserted in one transaction and nothing is created when one fails. In partial mode each link is created on its own. The response has one result per link, in the order of the request
+// @Tags links
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param mode query string false "Failure handling" Enums(atomic, partial) default(atomic)
+// @Param payload body []LinkCreateRequest true "Links to create"
+// @Success 201 {object} LinkBatchCreateResponse "Every link was created"
+// @Success 207 {object} LinkBatchCreateResponse "Partial mode, some links failed"
+// @Failure 400 {object} LinkBatchCreateResponse "Atomic mode, a link is invalid"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 409 {object} LinkBatchCreateResponse "Atomic mode, a hash is already in use"
+// @Failure 413 {object} problem.Problem "Too many links or bytes in the batch"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/batch [post]
+func (handler *LinkHandler) CreateLinks() http.HandlerFunc {
+	maxSize := handler.Config.Links.BatchMaxSize
+	if maxSize <= 0 {
+		maxSize = DEFAULT_BATCH_MAX_SIZE
+	}
+	maxBytes := int64(maxSize) * MAX_BATCH_LINK_BYTES
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = BATCH_MODE_ATOMIC
+		}
+		if mode != BATCH_MODE_ATOMIC && mode != BATCH_MODE_PARTIAL {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "mode must be atomic or partial")
+			return
+		}
+
+		payloads, err := req.Decode[[]LinkCreateRequest](http.MaxBytesReader(w, r.Body, maxBytes))
+		defer r.Body.Close()
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handler.Logger.Warn().Int64("max_bytes", maxBytesErr.Limit).Msg("Batch body is too large")
+			problem.Respond(w, r, http.StatusRequestEntityTooLarge, problem.CODE_BATCH_TOO_LARGE,
+				"A batch body holds at most "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes")
+			return
+		}
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Failed to process batch create request")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_BODY, err.Error())
+			return
+		}
+		if len(payloads) == 0 {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_BODY, "The batch has no links")
+			return
+		}
+		if len(payloads) > maxSize {
+			handler.Logger.Warn().Int("size", len(payloads)).Int("max_size", maxSize).Msg("Batch is too large")
+			problem.Respond(w, r, http.StatusRequestEntityTooLarge, problem.CODE_BATCH_TOO_LARGE,
+				"A batch holds at most "+strconv.Itoa(maxSize)+" links, got "+strconv.Itoa(len(payloads)))
+			return
+		}
+
+		response := LinkBatchCreateResponse{
+			Mode:    mode,
+			Results: make([]LinkBatchItemResult, len(payloads)),
+		}
+
+		// Links that pass validation, with their position in the request
+		links := make([]*Link, 0, len(payloads))
+		indexes := make([]int, 0, len(payloads))
+		for i := range payloads {
+			response.Results[i].Index = i
+
+			var p *problem.Problem
+			if err := req.IsValid(payloads[i]); err != nil {
+				p = req.ValidationProblem(req.FieldErrors(err)...)
+			}
+			var link *Link
+			if p == nil {
+				link, p = handler.newLink(r, &payloads[i], userId)
+			}
+			if p != nil {
+				response.Results[i].Status = p.Status
+				response.Results[i].Error = p
+				continue
+			}
+
+			links = append(links, link)
+			indexes = append(indexes, i)
+		}
+
+		atomic := mode == BATCH_MODE_ATOMIC
+		if atomic && len(links) < len(payloads) {
+			// Nothing is inserted when a link of an atomic batch is invalid
+			for _, i := range indexes {
+				response.Results[i] = abortedResult(i)
+			}
+			links = nil
+		}
+
+		errs := handler.LinkService.CreateLinks(links, atomic)
+		for j, link := range links {
+			i := indexes[j]
+			switch err := errs[j]; {
+			case err == nil:
+				handler.LinkService.Cache.Invalidate(link.Hash)
+				response.Results[i].Status = http.StatusCreated
+				response.Results[i].Link = link
+			case errors.Is(err, ErrBatchAborted):
+				response.Results[i] = abortedResult(i)
+			default:
+				p := handler.createProblem(link, err)
+				response.Results[i].Status = p.Status
+				response.Results[i].Error = p
+			}
+		}
+
+		status := http.StatusCreated
+		for _, result := range response.Results {
+			if result.Error == nil {
+				response.Created++
+				continue
+			}
+
+			response.Failed++
+			if status != http.StatusCreated {
+				continue
+			}
+			switch {
+			case !atomic:
+				status = http.StatusMultiStatus
+			case result.Error.Code != problem.CODE_BATCH_ABORTED:
+				status = result.Status
+			}
+		}
+
+		handler.Logger.Info().
+			Str("user_id", userId).
+			Str("mode", mode).
+			Int("created", response.Created).
+			Int("failed", response.Failed).
+			Msg("Link batch processed")
+
+		res.Json(w, response, status)
+	}
+}
+
+func abortedResult(index int) LinkBatchItemResult {
+	p := problem.New(http.StatusFailedDependency, problem.CODE_BATCH_ABORTED, "Not created because another link of the atomic batch failed")
+	return LinkBatchItemResult{
+		Index:  index,
+		Status: p.Status,
+		Error:  p,
+	}
+}
+
+func importTimedOut() *problem.Problem {
+	return problem.New(http.StatusServiceUnavailable, problem.CODE_TIMED_OUT, "Not imported, the import ran out of time")
+}
+
+// ImportLinks godoc
+// @Summary Import links
+// @Description Creates links from CSV, a JSON array or newline-delimited JSON, as produced by the export endpoint. The format comes from the format parameter or the Content-Type. Every row is checked and created on its own like a single create, a custom hash already in use fails its row with 409. In sequence hash mode generated codes cannot be imported, those links get a new code and their row reports the original one. Rows still waiting when the import runs out of time fail with 503. The report holds the outcome of every row
+// @Tags links
+// @Accept text/csv,json,application/x-ndjson
+// @Produce json
+// @Security ApiKeyAuth
+// @Param format query string false "Input format, defaults to the Content-Type, then csv" Enums(csv, json, ndjson)
+// @Param payload body []LinkExportRow true "Links to import, CSV needs a header with at least a url column"
+// @Success 201 {object} LinkImportResponse "Every row was imported"
+// @Success 207 {object} LinkImportResponse "Some rows failed"
+// @Failure 400 {object} problem.Problem "The body cannot be read"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 413 {object} problem.Problem "Too many rows or body too large"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/import [post]
+func (handler *LinkHandler) ImportLinks() http.HandlerFunc {
+	maxRows := handler.Config.Links.ImportMaxRows
+	if maxRows <= 0 {
+		maxRows = DEFAULT_IMPORT_MAX_ROWS
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
+		format, err := transferFormat(r.URL.Query().Get("format"), r.Header.Get("Content-Type"))
+		if err != nil {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, err.Error())
+			return
+		}
+
+		controller := http.NewResponseController(w)
+		deadline := time.Now().Add(IMPORT_TIMEOUT)
+		if err := controller.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			handler.Logger.Error().Err(err).Msg("Failed to extend the import read deadline")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to start the import")
+			return
+		}
+		if err := controller.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			handler.Logger.Error().Err(err).Msg("Failed to extend the import write deadline")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to start the import")
+			return
+		}
+
+		body := http.MaxBytesReader(w, r.Body, MAX_IMPORT_BYTES)
+		defer body.Close()
+
+		// Rows are read before any is created, so that an oversized import
+		// is refused as a whole
+		type importRow struct {
+			payload *LinkCreateRequest
+			problem *problem.Problem
+		}
+		var rows []importRow
+
+		decoder, err := newLinkDecoder(format, body)
+		if err != nil {
+			handler.Logger.Error().Err(err).Str("format", format).Msg("Failed to read import")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_BODY, err.Error())
+			return
+		}
+		for err == nil {
+			var payload *LinkCreateRequest
+			payload, err = decoder.Next()
+
+			var rowErr *rowError
+			if errors.As(err, &rowErr) {
+				rows = append(rows, importRow{problem: problem.New(http.StatusBadRequest, problem.CODE_INVALID_BODY, rowErr.Error())})
+				err = nil
+			} else if err == nil {
+				rows = append(rows, importRow{payload: payload})
+			}
+
+			if len(rows) > maxRows {
+				handler.Logger.Warn().Int("max_rows", maxRows).Msg("Import is too large")
+				problem.Respond(w, r, http.StatusRequestEntityTooLarge, problem.CODE_BATCH_TOO_LARGE,
+					"An import holds at most "+strconv.Itoa(maxRows)+" links")
+				return
+			}
+		}
+		if !errors.Is(err, io.EOF) {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				problem.Respond(w, r, http.StatusRequestEntityTooLarge, problem.CODE_BATCH_TOO_LARGE,
+					"An import body holds at most "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes")
+				return
+			}
+
+			handler.Logger.Error().Err(err).Str("format", format).Int("row", len(rows)+1).Msg("Failed to read import")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_BODY,
+				"Row "+strconv.Itoa(len(rows)+1)+": "+err.Error())
+			return
+		}
+		if len(rows) == 0 {
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_BODY, "The import has no links")
+			return
+		}
+
+		// URL checks and password hashing make rows slow, the ones left when
+		// the time is up fail so the report still fits in the deadline
+		work, cancel := context.WithDeadline(r.Context(), deadline.Add(-IMPORT_REPORT_MARGIN))
+		defer cancel()
+		workRequest := r.WithContext(work)
+
+		response := LinkImportResponse{
+			Format:  format,
+			Results: make([]LinkImportRowResult, len(rows)),
+		}
+		for i, row := range rows {
+			result := &response.Results[i]
+			result.Row = i + 1
+
+			p := row.problem
+			if p == nil && work.Err() != nil {
+				p = importTimedOut()
+			}
+			if p == nil {
+				if err := req.IsValid(*row.payload); err != nil {
+					p = req.ValidationProblem(req.FieldErrors(err)...)
+				}
+			}
+			// Generated codes of an export are reserved for the sequence,
+			// the link gets a new one
+			if p == nil && handler.LinkService.IsReservedHash(row.payload.Hash) {
+				result.OriginalHash = row.payload.Hash
+				row.payload.Hash = ""
+			}
+			var link *Link
+			if p == nil {
+				link, p = handler.newLink(workRequest, row.payload, userId)
+				// A URL check cut short by the deadline is not the row's fault
+				if p != nil && work.Err() != nil {
+					p = importTimedOut()
+				}
+			}
+			if p == nil {
+				if _, err := handler.LinkService.CreateLink(link); err != nil {
+					p = handler.createProblem(link, err)
+				}
+			}
+
+			if p != nil {
+				result.Status = p.Status
+				result.Error = p
+				response.Failed++
+				continue
+			}
+
+			handler.LinkService.Cache.Invalidate(link.Hash)
+			result.Status = http.StatusCreated
+			result.Hash = link.Hash
+			response.Imported++
+		}
+
+		status := http.StatusCreated
+		if response.Failed > 0 {
+			status = http.StatusMultiStatus
+		}
+
+		handler.Logger.Info().
+			Str("user_id", userId).
+			Str("format", format).
+			Int("imported", response.Imported).
+			Int("failed", response.Failed).
+			Msg("Links imported")
+
+		res.Json(w, response, status)
+	}
+}
+
+// BulkDelete godoc
+// @Summary Delete links in bulk
+// @Description Deletes the given hashes, or every link matching the filter, in one transaction and reports the outcome of each hash: deleted or not_found
+// @Tags links
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body LinkBulkRequest true "Hashes or filter selecting the links"
+// @Success 200 {object} LinkBulkResponse "Every selected link was deleted"
+// @Success 207 {object} LinkBulkResponse "Some hashes were not found"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 413 {object} problem.Problem "Too many links selected"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/bulk/delete [post]
+func (handler *LinkHandler) BulkDelete() http.HandlerFunc {
+	return handler.bulk(BULK_ACTION_DELETE)
+}
+
+// BulkRestore godoc
+// @Summary Restore deleted links in bulk
+// @Description Restores the given hashes, or every deleted link matching the filter, in one transaction and reports the outcome of each hash: restored, hash_taken when a live link uses the hash again, or not_found
+// @Tags links
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body LinkBulkRequest true "Hashes or filter selecting the deleted links"
+// @Success 200 {object} LinkBulkResponse "Every selected link was restored"
+// @Success 207 {object} LinkBulkResponse "Some links could not be restored"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 413 {object} problem.Problem "Too many links selected"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/bulk/restore [post]
+func (handler *LinkHandler) BulkRestore() http.HandlerFunc {
+	return handler.bulk(BULK_ACTION_RESTORE)
+}
+
+// BulkExtend godoc
+// @Summary Extend links expiry in bulk
+// @Description Extends the expiry of the given hashes, or of every link matching the filter, by the given days and hours (1 day when both are omitted) in one transaction, and reports the outcome of each hash: extended, no_expiry or not_found
+// @Tags links
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body LinkBulkExtendRequest true "Hashes or filter selecting the links, and extension"
+// @Success 200 {object} LinkBulkResponse "Every selected link was extended"
+// @Success 207 {object} LinkBulkResponse "Some links could not be extended"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 413 {object} problem.Problem "Too many links selected"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/bulk/extend [post]
+func (handler *LinkHandler) BulkExtend() http.HandlerFunc {
+	return handler.bulk(BULK_ACTION_EXTEND)
+}
+
+// bulk serves the bulk endpoints, which only differ by action. The
+// extension fields are ignored by the actions other than extend.
+func (handler *LinkHandler) bulk(action string) http.HandlerFunc {
+	maxSize := handler.Config.Links.BatchMaxSize
+	if maxSize <= 0 {
+		maxSize = DEFAULT_BATCH_MAX_SIZE
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
 			return
 		}
 
-		link, err := handler.LinkRepository.GetLinkByHash(hash, "")
+		payload, err := req.HandleBody[LinkBulkExtendRequest](&w, r)
 		if err != nil {
-			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to find link by hash")
-			http.Error(w, "Link not found", http.StatusNotFound)
+			handler.Logger.Error().Err(err).Str("action", action).Msg("Failed to process bulk request")
+			return
+		}
+
+		filter, p := bulkFilter(&payload.LinkBulkRequest, userId)
+		if p != nil {
+			problem.Write(w, r, p)
 			return
 		}
+		if len(filter.Hashes) > maxSize {
+			handler.Logger.Warn().Int("size", len(filter.Hashes)).Int("max_size", maxSize).Msg("Bulk request is too large")
+			problem.Respond(w, r, http.StatusRequestEntityTooLarge, problem.CODE_BATCH_TOO_LARGE,
+				"A bulk operation changes at most "+strconv.Itoa(maxSize)+" links, got "+strconv.Itoa(len(filter.Hashes)))
+			return
+		}
+
+		if payload.Days == 0 && payload.Hours == 0 {
+			payload.Days = DEFAULT_EXTEND_DAYS
+		}
+		extension := time.Duration(payload.Days)*24*time.Hour + time.Duration(payload.Hours)*time.Hour
 
-		err = handler.LinkRepository.IncrementClicksCount(link)
+		outcomes, err := handler.LinkService.BulkApply(action, *filter, extension, maxSize)
 		if err != nil {
-			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to update click count")
+			if errors.Is(err, ErrBulkTooLarge) {
+				handler.Logger.Warn().Err(err).Str("user_id", userId).Str("action", action).Msg("Bulk filter selects too many links")
+				problem.Respond(w, r, http.StatusRequestEntityTooLarge, problem.CODE_BATCH_TOO_LARGE,
+					"The filter selects more than "+strconv.Itoa(maxSize)+" links, narrow it down")
+				return
+			}
+
+			handler.Logger.Error().Err(err).Str("user_id", userId).Str("action", action).Msg("Failed to apply bulk operation")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to apply bulk operation")
+			return
+		}
+
+		response := LinkBulkResponse{
+			Action:  action,
+			Results: outcomes,
+		}
+		for _, outcome := range outcomes {
+			if outcome.Status != BULK_STATUS_NOT_FOUND {
+				response.Matched++
+			}
+			if outcome.Succeeded() {
+				response.Succeeded++
+			} else {
+				response.Failed++
+			}
+		}
+
+		status := http.StatusOK
+		if response.Failed > 0 {
+			status = http.StatusMultiStatus
 		}
 
 		handler.Logger.Info().
-			Str("hash", hash).
-			Str("url", link.Url).
-			Int64("clicks", link.NumberOfClicks).
-			Msg("Redirecting to URL")
+			Str("user_id", userId).
+			Str("action", action).
+			Int("matched", response.Matched).
+			Int("succeeded", response.Succeeded).
+			Int("failed", response.Failed).
+			Msg("Bulk operation applied")
 
-		http.Redirect(w, r, link.Url, http.StatusFound)
+		res.Json(w, response, status)
 	}
 }
 
-// GetLink godoc
-// @Summary Get link details
-// @Description Get details of a specific shortened link by hash
+// bulkFilter turns the selection of a bulk request into a filter on the
+// links of the user. Exactly one of hashes and filter must be given, and a
+// filter needs at least one criterion so that an empty body cannot select
+// every link.
+func bulkFilter(payload *LinkBulkRequest, userId string) (*LinkFilter, *problem.Problem) {
+	if (len(payload.Hashes) == 0) == (payload.Filter == nil) {
+		return nil, problem.New(http.StatusBadRequest, problem.CODE_INVALID_BODY, "Give either hashes or a filter")
+	}
+
+	filter := &LinkFilter{UserId: userId}
+	if len(payload.Hashes) > 0 {
+		seen := make(map[string]bool, len(payload.Hashes))
+		for _, hash := range payload.Hashes {
+			if !seen[hash] {
+				seen[hash] = true
+				filter.Hashes = append(filter.Hashes, hash)
+			}
+		}
+		return filter, nil
+	}
+
+	criteria := payload.Filter
+	filter.Tag = strings.ToLower(strings.TrimSpace(criteria.Tag))
+	filter.Domain = strings.ToLower(strings.TrimSpace(criteria.Domain))
+	filter.CreatedFrom = criteria.CreatedFrom
+	filter.CreatedTo = criteria.CreatedTo
+
+	if filter.Tag == "" && filter.Domain == "" && filter.CreatedFrom == nil && filter.CreatedTo == nil {
+		return nil, req.ValidationProblem(problem.FieldError{
+			Field:   "filter",
+			Rule:    "required",
+			Message: "filter needs at least one of tag, created_from, created_to or domain",
+		})
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return nil, req.ValidationProblem(problem.FieldError{
+			Field:   "created_to",
+			Rule:    "after",
+			Message: "created_to must be after created_from",
+		})
+	}
+
+	return filter, nil
+}
+
+// UpdateLink godoc
+// @Summary Update a shortened link
+// @Description Changes the destination URL, hash, expiry, title or tags of a link. Only fields present in the body are changed, a null expires_at removes the expiry
 // @Tags links
+// @Accept json
 // @Produce json
-// @Param user_id query string true "User ID of the link owner"
-// @Param hash query string true "Hash of the shortened link"
-// @Success 200 {object} Link "Link details"
-// @Failure 400 {string} string "Missing parameters"
-// @Failure 403 {string} string "Link not found or user does not have access"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/v1/links [get]
-func (handler *LinkHandler) GetLink() http.HandlerFunc {
+// @Security ApiKeyAuth
+// @Param hash path string true "Current hash of the shortened link"
+// @Param payload body LinkUpdateRequest true "Fields to change"
+// @Success 200 {object} Link "Updated link"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 403 {object} problem.Problem "Link not found or user does not have access"
+// @Failure 409 {object} problem.Problem "Hash already exists"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/{hash} [patch]
+func (handler *LinkHandler) UpdateLink() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
 
-		userId := r.URL.Query().Get("user_id")
-		hash := r.URL.Query().Get("hash")
+		hash := r.PathValue("hash")
 
-		if userId == "" {
-			handler.Logger.Error().Msg("User ID is required")
-			res.Json(w, "User ID is required", http.StatusBadRequest)
+		payload, err := req.HandleBody[LinkUpdateRequest](&w, r)
+		if err != nil {
+			handler.Logger.Error().Err(err).Msg("Failed to process update link request")
 			return
 		}
 
-		if hash == "" {
-			handler.Logger.Error().Msg("Hash is required")
-			res.Json(w, "Hash is required", http.StatusBadRequest)
+		if payload.ExpiresAt.Time != nil && !payload.ExpiresAt.Time.After(time.Now()) {
+			handler.Logger.Error().Time("expires_at", *payload.ExpiresAt.Time).Msg("Expiry is in the past")
+			problem.Write(w, r, req.ValidationProblem(problem.FieldError{
+				Field:   "expires_at",
+				Rule:    "future",
+				Message: "expires_at must be in the future",
+			}))
 			return
 		}
 
-		link, err := handler.LinkRepository.GetLinkByHash(hash, userId)
+		link, err := handler.LinkStore.GetLinkByHash(hash, userId)
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
+			if errors.Is(err, ErrNotFound) {
 				handler.Logger.Error().
 					Err(err).
 					Str("hash", hash).
 					Str("user_id", userId).
 					Msg("Link not found or user does not have access")
-				res.Json(w, "Link not found or user does not have access", http.StatusForbidden)
+				problem.Respond(w, r, http.StatusForbidden, problem.CODE_FORBIDDEN, "Link not found or user does not have access")
 				return
 			}
 
 			handler.Logger.Error().Err(err).Str("hash", hash).Msg("Failed to find link")
-			res.Json(w, "Failed to retrieve link", http.StatusInternalServerError)
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve link")
+			return
+		}
+
+		if payload.Url != nil && *payload.Url != link.Url {
+			if err := handler.LinkService.CheckUrl(r.Context(), *payload.Url, r.Host); err != nil {
+				problem.Write(w, r, handler.urlProblem(*payload.Url, err))
+				return
+			}
+			link.Url = *payload.Url
+		}
+		if payload.Hash != nil && *payload.Hash != link.Hash {
+			newHash, err := handler.LinkService.CheckCustomHash(*payload.Hash)
+			if err != nil {
+				handler.Logger.Warn().Err(err).Str("hash", *payload.Hash).Msg("Custom hash refused by policy")
+				problem.Write(w, r, req.ValidationProblem(hashPolicyError(err)))
+				return
+			}
+			link.Hash = newHash
+		}
+		if payload.ExpiresAt.Set && payload.ExpiresAt.Time == nil {
+			link.ExpiresAt = nil
+		} else if payload.ExpiresAt.Set {
+			expiresAt := payload.ExpiresAt.Time.UTC()
+			link.ExpiresAt = &expiresAt
+		}
+		if payload.Title != nil {
+			link.Title = *payload.Title
+		}
+		if payload.Tags != nil {
+			link.Tags = NormalizeTags(*payload.Tags)
+		}
+		if payload.Password != nil && *payload.Password == "" {
+			link.setPasswordHash("")
+		} else if payload.Password != nil {
+			passwordHash, p := handler.hashPassword(*payload.Password)
+			if p != nil {
+				problem.Write(w, r, p)
+				return
+			}
+			link.setPasswordHash(passwordHash)
+		}
+
+		updatedLink, err := handler.LinkStore.Update(link)
+		if err != nil {
+			if errors.Is(err, ErrHashTaken) {
+				handler.Logger.Warn().
+					Str("hash", hash).
+					Str("new_hash", link.Hash).
+					Msg("Attempted to rename link to existing hash")
+				problem.Respond(w, r, http.StatusConflict, problem.CODE_HASH_TAKEN, hashTakenDetail(link.Hash, err))
+				return
+			}
+
+			// The link was deleted since it was read
+			if errors.Is(err, ErrNotFound) {
+				handler.Logger.Warn().
+					Str("hash", hash).
+					Str("user_id", userId).
+					Msg("Link disappeared before it was updated")
+				problem.Respond(w, r, http.StatusForbidden, problem.CODE_FORBIDDEN, "Link not found or user does not have access")
+				return
+			}
+
+			handler.Logger.Error().
+				Err(err).
+				Str("hash", hash).
+				Msg("Failed to update link")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to update link")
 			return
 		}
 
+		handler.LinkService.Cache.Invalidate(hash, updatedLink.Hash)
+
 		handler.Logger.Info().
 			Str("hash", hash).
-			Str("url", link.Url).
-			Str("user_id", link.UserId).
-			Msg("Link details retrieved successfully")
+			Str("new_hash", updatedLink.Hash).
+			Str("user_id", updatedLink.UserId).
+			Msg("Link updated successfully")
 
-		res.Json(w, link, http.StatusOK)
+		res.Json(w, updatedLink, http.StatusOK)
 	}
 }
 
-// GetAllLinks godoc
-// @Summary Get all user links
-// @Description Get a list of all links belonging to a user
+// GetTrash godoc
+// @Summary List deleted links
+// @Description Lists the deleted links of the authenticated user, most recently deleted first. They can be restored until purge_at, after which they are removed for good
 // @Tags links
 // @Produce json
-// @Param user_id query string true "User ID"
+// @Security ApiKeyAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
-// @Success 200 {object} GetAllLinksResponse "List of links"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/v1/links/all [get]
-func (handler *LinkHandler) GetAllLinks() http.HandlerFunc {
+// @Success 200 {object} GetTrashResponse "Deleted links"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/trash [get]
+func (handler *LinkHandler) GetTrash() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userId := r.URL.Query().Get("user_id")
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
 
 		page := 1
 		limit := 10
@@ -168,157 +1473,122 @@ func (handler *LinkHandler) GetAllLinks() http.HandlerFunc {
 			}
 		}
 
-		if userId == "" {
-			handler.Logger.Warn().Msg("User ID is missing, returning empty result")
-			emptyResponse := GetAllLinksResponse{
-				Links:      []Link{},
-				TotalPages: 0,
-				TotalLinks: 0,
-				Page:       page,
-				Limit:      limit,
-			}
-			res.Json(w, emptyResponse, http.StatusOK)
-			return
-		}
-
-		exists, err := handler.LinkRepository.CheckUserExists(userId)
+		result, err := handler.LinkStore.GetDeletedLinks(userId, page, limit)
 		if err != nil {
-			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Failed to check user existence")
-			res.Json(w, "Failed to check user existence", http.StatusInternalServerError)
-			return
-		}
-
-		if !exists {
-			handler.Logger.Info().Str("user_id", userId).Msg("User ID does not exist, returning empty result")
-			emptyResponse := GetAllLinksResponse{
-				Links:      []Link{},
-				TotalPages: 0,
-				TotalLinks: 0,
-				Page:       page,
-				Limit:      limit,
-			}
-			res.Json(w, emptyResponse, http.StatusOK)
+			handler.Logger.Error().Err(err).Str("user_id", userId).Msg("Failed to get deleted links")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to retrieve deleted links")
 			return
 		}
 
-		result, err := handler.LinkRepository.GetAllLinks(userId, page, limit)
-		if err != nil {
-			handler.Logger.Error().Err(err).Msg("Failed to get links")
-			res.Json(w, err.Error(), http.StatusInternalServerError)
-			return
+		links := make([]TrashedLink, 0, len(result.Links))
+		for _, link := range result.Links {
+			links = append(links, TrashedLink{
+				Link:    link,
+				PurgeAt: handler.LinkService.PurgeAt(&link),
+			})
 		}
 
 		handler.Logger.Info().
 			Str("user_id", userId).
 			Int("total_links", int(result.TotalLinks)).
 			Int("page", result.Page).
-			Int("total_pages", result.TotalPages).
-			Msg("Successfully retrieved user links")
+			Msg("Successfully retrieved deleted links")
 
-		response := GetAllLinksResponse{
-			Links:      result.Links,
+		res.Json(w, GetTrashResponse{
+			Links:      links,
 			TotalPages: result.TotalPages,
 			TotalLinks: result.TotalLinks,
 			Page:       result.Page,
 			Limit:      result.Limit,
-		}
-
-		res.Json(w, response, http.StatusOK)
+		}, http.StatusOK)
 	}
 }
 
-// CreateLink godoc
-// @Summary Create a new shortened link
-// @Description Creates a new shortened link
+// RestoreLink godoc
+// @Summary Restore a deleted link
+// @Description Brings a link back from the trash. A link that had expired is deleted again by the next lifetime run unless its expiry is extended
 // @Tags links
-// @Accept json
 // @Produce json
-// @Param payload body LinkCreateRequest true "Data for creating a link"
-// @Success 201 {object} Link "Created link"
-// @Failure 400 {string} string "Error in request parameters"
-// @Failure 404 {string} string "User ID not found"
-// @Failure 409 {string} string "Hash already exists"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/v1/links [post]
-func (handler *LinkHandler) CreateLink() http.HandlerFunc {
+// @Security ApiKeyAuth
+// @Param hash path string true "Hash of the deleted link"
+// @Success 200 {object} Link "Restored link"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 404 {object} problem.Problem "No deleted link with this hash"
+// @Failure 409 {object} problem.Problem "Another link holds the hash"
+// @Failure 500 {object} problem.Problem "Internal server error"
+// @Router /api/v1/links/{hash}/restore [post]
+func (handler *LinkHandler) RestoreLink() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		payload, err := req.HandleBody[LinkCreateRequest](&w, r)
-		if err != nil {
-			handler.Logger.Error().Err(err).Msg("Failed to process create link request")
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
 			return
 		}
 
-		if payload.UserId != "" {
-			exists, err := handler.LinkRepository.CheckUserExists(payload.UserId)
-			if err != nil {
-				handler.Logger.Error().Err(err).Str("user_id", payload.UserId).Msg("Failed to check user existence")
-				res.Json(w, "Failed to check user existence", http.StatusInternalServerError)
-				return
-			}
+		hash := r.PathValue("hash")
 
-			if !exists {
-				handler.Logger.Error().Str("user_id", payload.UserId).Msg("User ID does not exist")
-				res.Json(w, "User ID not found", http.StatusNotFound)
+		link, err := handler.LinkService.RestoreLink(hash, userId)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				handler.Logger.Warn().
+					Str("hash", hash).
+					Str("user_id", userId).
+					Msg("No deleted link to restore")
+				problem.Respond(w, r, http.StatusNotFound, problem.CODE_NOT_FOUND, "No deleted link with hash "+strconv.Quote(hash))
 				return
 			}
 
-			handler.Logger.Info().Str("user_id", payload.UserId).Msg("User ID exists, using it for the new link")
-		}
-
-		link := &Link{
-			Url:            payload.Url,
-			Hash:           payload.Hash,
-			UserId:         payload.UserId,
-			Lifetime:       DEFAULT_LIFETIME_DAYS,
-			NumberOfClicks: DEFAULT_NUMBER_OF_CLICKS,
-		}
-
-		createdLink, err := handler.LinkRepository.Create(link)
-		if err != nil {
-			if err.Error() == "hash already exists" {
+			if errors.Is(err, ErrHashTaken) {
 				handler.Logger.Warn().
-					Str("url", payload.Url).
-					Str("hash", payload.Hash).
-					Msg("Attempted to create link with existing hash")
-				res.Json(w, "Hash already exists", http.StatusConflict)
+					Str("hash", hash).
+					Str("user_id", userId).
+					Msg("Attempted to restore link whose hash is in use")
+				problem.Respond(w, r, http.StatusConflict, problem.CODE_HASH_TAKEN, "Hash "+strconv.Quote(hash)+" is used by another link")
 				return
 			}
 
 			handler.Logger.Error().
 				Err(err).
-				Str("url", payload.Url).
-				Msg("Failed to create link")
-			res.Json(w, "Failed to create link", http.StatusInternalServerError)
+				Str("hash", hash).
+				Msg("Failed to restore link")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to restore link")
 			return
 		}
 
 		handler.Logger.Info().
-			Str("url", createdLink.Url).
-			Str("hash", createdLink.Hash).
-			Str("user_id", createdLink.UserId).
-			Int64("lifetime", createdLink.Lifetime).
-			Msg("Link created successfully")
+			Str("hash", hash).
+			Str("user_id", userId).
+			Msg("Link restored successfully")
 
-		res.Json(w, createdLink, http.StatusCreated)
+		res.Json(w, link, http.StatusOK)
 	}
 }
 
 // DeleteLink godoc
 // @Summary Delete a shortened link
-// @Description Deletes a shortened link by hash
+// @Description Moves a shortened link to the trash. It can be restored, and its hash stays reserved, until the trash retention has passed
 // @Tags links
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param payload body LinkDeleteRequest true "Data for deleting a link"
 // @Success 200 {string} string "Link deleted successfully"
-// @Failure 400 {string} string "Error in request parameters"
-// @Failure 403 {string} string "Link not found or user does not have permission"
-// @Failure 404 {string} string "Link not found"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 403 {object} problem.Problem "Link not found or user does not have permission"
+// @Failure 404 {object} problem.Problem "Link not found"
+// @Failure 500 {object} problem.Problem "Internal server error"
 // @Router /api/v1/links [delete]
 func (handler *LinkHandler) DeleteLink() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
 		payload, err := req.HandleBody[LinkDeleteRequest](&w, r)
 		if err != nil {
 			handler.Logger.Error().Err(err).Msg("Failed to process delete link request")
@@ -327,33 +1597,27 @@ func (handler *LinkHandler) DeleteLink() http.HandlerFunc {
 
 		if payload.Hash == "" {
 			handler.Logger.Error().Msg("Hash is required")
-			res.Json(w, "Hash is required", http.StatusBadRequest)
-			return
-		}
-
-		if payload.UserId == "" {
-			handler.Logger.Error().Msg("User ID is required")
-			res.Json(w, "User ID is required", http.StatusBadRequest)
+			problem.Respond(w, r, http.StatusBadRequest, problem.CODE_INVALID_PARAMETER, "Hash is required")
 			return
 		}
 
-		err = handler.LinkRepository.DeleteLink(payload.Hash, payload.UserId)
+		err = handler.LinkStore.DeleteLink(payload.Hash, userId)
 		if err != nil {
-			if err.Error() == "link not found or user does not have permission" {
+			if errors.Is(err, ErrForbidden) {
 				handler.Logger.Error().
 					Err(err).
 					Str("hash", payload.Hash).
-					Str("user_id", payload.UserId).
+					Str("user_id", userId).
 					Msg("User does not have permission or link not found")
-				res.Json(w, "Link not found or user does not have permission", http.StatusForbidden)
+				problem.Respond(w, r, http.StatusForbidden, problem.CODE_FORBIDDEN, "Link not found or user does not have permission")
 				return
 			}
 
-			if err.Error() == "link not found or already deleted" {
+			if errors.Is(err, ErrNotFound) {
 				handler.Logger.Warn().
 					Str("hash", payload.Hash).
 					Msg("Link not found or already deleted")
-				res.Json(w, "Link not found", http.StatusNotFound)
+				problem.Respond(w, r, http.StatusNotFound, problem.CODE_NOT_FOUND, "Link not found")
 				return
 			}
 
@@ -361,13 +1625,15 @@ func (handler *LinkHandler) DeleteLink() http.HandlerFunc {
 				Err(err).
 				Str("hash", payload.Hash).
 				Msg("Failed to delete link")
-			res.Json(w, "Failed to delete link", http.StatusInternalServerError)
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to delete link")
 			return
 		}
 
+		handler.LinkService.Cache.Invalidate(payload.Hash)
+
 		handler.Logger.Info().
 			Str("hash", payload.Hash).
-			Str("user_id", payload.UserId).
+			Str("user_id", userId).
 			Msg("Link deleted successfully")
 
 		res.Json(w, "Link deleted successfully", http.StatusOK)
@@ -375,54 +1641,224 @@ func (handler *LinkHandler) DeleteLink() http.HandlerFunc {
 }
 
 // AddDays godoc
-// @Summary Add days to all user links
-// @Description Increases the lifetime of all links belonging to a user by 1 day
+// @Summary Extend link expiry
+// @Description Extends the expiry of one link, or of all links belonging to the authenticated user when no hash is given, by the given number of days and hours (1 day when both are omitted)
 // @Tags links
 // @Accept json
 // @Produce json
-// @Param payload body AddDaysRequest true "User ID"
+// @Security ApiKeyAuth
+// @Param payload body AddDaysRequest true "Optional hash and extension"
 // @Success 200 {object} map[string]interface{} "Success message with number of updated links"
-// @Failure 400 {string} string "Error in request parameters"
-// @Failure 404 {string} string "User not found"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} problem.Problem "Error in request parameters"
+// @Failure 401 {object} problem.Problem "Authentication required"
+// @Failure 403 {object} problem.Problem "Link not found or user does not have permission"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 500 {object} problem.Problem "Internal server error"
 // @Router /api/v1/links/add-days [post]
 func (handler *LinkHandler) AddDays() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := middleware.UserIdFromContext(r.Context())
+		if !ok {
+			handler.Logger.Error().Msg("Authentication required")
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Authentication required")
+			return
+		}
+
 		payload, err := req.HandleBody[AddDaysRequest](&w, r)
 		if err != nil {
 			handler.Logger.Error().Err(err).Msg("Failed to process add days request")
 			return
 		}
 
-		updatedCount, err := handler.LinkRepository.AddDaysToAllUserLinks(payload.UserId)
+		if payload.Days == 0 && payload.Hours == 0 {
+			payload.Days = DEFAULT_EXTEND_DAYS
+		}
+		extension := time.Duration(payload.Days)*24*time.Hour + time.Duration(payload.Hours)*time.Hour
+
+		updatedCount, err := handler.LinkStore.ExtendExpiry(userId, payload.Hash, extension)
 		if err != nil {
-			if err.Error() == "user not found" {
+			if errors.Is(err, ErrUserNotFound) {
 				handler.Logger.Error().
 					Err(err).
-					Str("user_id", payload.UserId).
+					Str("user_id", userId).
 					Msg("User not found")
-				res.Json(w, "User not found", http.StatusNotFound)
+				problem.Respond(w, r, http.StatusNotFound, problem.CODE_NOT_FOUND, "User not found")
+				return
+			}
+
+			if errors.Is(err, ErrForbidden) {
+				handler.Logger.Error().
+					Err(err).
+					Str("hash", payload.Hash).
+					Str("user_id", userId).
+					Msg("User does not have permission or link not found")
+				problem.Respond(w, r, http.StatusForbidden, problem.CODE_FORBIDDEN, "Link not found or user does not have permission")
 				return
 			}
 
 			handler.Logger.Error().
 				Err(err).
-				Str("user_id", payload.UserId).
-				Msg("Failed to add days to user links")
-			res.Json(w, "Failed to add days to user links", http.StatusInternalServerError)
+				Str("user_id", userId).
+				Msg("Failed to extend links expiry")
+			problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to extend links expiry")
 			return
 		}
 
 		handler.Logger.Info().
-			Str("user_id", payload.UserId).
+			Str("user_id", userId).
+			Str("hash", payload.Hash).
+			Dur("extension", extension).
 			Int64("updated_links", updatedCount).
-			Msg("Successfully added days to user links")
+			Msg("Successfully extended links expiry")
 
 		response := map[string]interface{}{
-			"message":       "Successfully added days to user links",
+			"message":       "Successfully extended links expiry",
 			"updated_links": updatedCount,
 		}
 
 		res.Json(w, response, http.StatusOK)
 	}
 }
+
+// hashPolicyError turns a refused custom hash into a field error of the
+// validation problem.
+func hashPolicyError(err error) problem.FieldError {
+	fieldError := problem.FieldError{
+		Field:   "hash",
+		Rule:    "policy",
+		Message: err.Error(),
+	}
+
+	var policyErr *HashPolicyError
+	if errors.As(err, &policyErr) {
+		fieldError.Rule = policyErr.Rule
+	}
+
+	return fieldError
+}
+
+// urlProblem reports a destination refused by the URL policy as a
+// validation problem, and a failure of the check itself as a 500.
+func (handler *LinkHandler) urlProblem(url string, err error) *problem.Problem {
+	var violation *urlpolicy.Violation
+	if errors.As(err, &violation) {
+		handler.Logger.Warn().
+			Str("url", url).
+			Str("rule", violation.Rule).
+			Msg("Destination URL refused by policy")
+		return req.ValidationProblem(problem.FieldError{
+			Field:   "url",
+			Rule:    violation.Rule,
+			Message: violation.Message,
+		})
+	}
+
+	handler.Logger.Error().Err(err).Str("url", url).Msg("Failed to check destination URL")
+	return problem.New(http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to check destination URL")
+}
+
+// newLink applies the checks of a create request that struct tags cannot
+// express: expiry in the future, the URL policy and the vanity hash policy.
+// It returns the link to store, or the problem to answer with.
+func (handler *LinkHandler) newLink(r *http.Request, payload *LinkCreateRequest, userId string) (*Link, *problem.Problem) {
+	now := time.Now().UTC()
+	expiresAt := now.AddDate(0, 0, DEFAULT_LIFETIME_DAYS)
+	if payload.ExpiresAt != nil {
+		if !payload.ExpiresAt.After(now) {
+			handler.Logger.Error().Time("expires_at", *payload.ExpiresAt).Msg("Expiry is in the past")
+			return nil, req.ValidationProblem(problem.FieldError{
+				Field:   "expires_at",
+				Rule:    "future",
+				Message: "expires_at must be in the future",
+			})
+		}
+		expiresAt = payload.ExpiresAt.UTC()
+	}
+
+	if err := handler.LinkService.CheckUrl(r.Context(), payload.Url, r.Host); err != nil {
+		return nil, handler.urlProblem(payload.Url, err)
+	}
+
+	hash := payload.Hash
+	if hash != "" {
+		var err error
+		hash, err = handler.LinkService.CheckCustomHash(hash)
+		if err != nil {
+			handler.Logger.Warn().Err(err).Str("hash", payload.Hash).Msg("Custom hash refused by policy")
+			return nil, req.ValidationProblem(hashPolicyError(err))
+		}
+	}
+
+	link := &Link{
+		Url:            payload.Url,
+		Hash:           hash,
+		UserId:         userId,
+		NumberOfClicks: DEFAULT_NUMBER_OF_CLICKS,
+		ExpiresAt:      &expiresAt,
+		Title:          payload.Title,
+		Tags:           NormalizeTags(payload.Tags),
+	}
+
+	if payload.Password != "" {
+		passwordHash, p := handler.hashPassword(payload.Password)
+		if p != nil {
+			return nil, p
+		}
+		link.setPasswordHash(passwordHash)
+	}
+
+	return link, nil
+}
+
+// hashPassword hashes the password of a link, too short or too long
+// passwords are a validation problem.
+func (handler *LinkHandler) hashPassword(password string) (string, *problem.Problem) {
+	if len(password) < MIN_LINK_PASSWORD_LENGTH {
+		return "", req.ValidationProblem(problem.FieldError{
+			Field:   "password",
+			Rule:    "min",
+			Message: "password must be at least " + strconv.Itoa(MIN_LINK_PASSWORD_LENGTH) + " characters long",
+		})
+	}
+
+	passwordHash, err := handler.LinkService.Passwords.HashPassword(password)
+	if errors.Is(err, ErrPasswordTooLong) {
+		return "", req.ValidationProblem(problem.FieldError{
+			Field:   "password",
+			Rule:    "max",
+			Message: ErrPasswordTooLong.Error(),
+		})
+	}
+	if err != nil {
+		handler.Logger.Error().Err(err).Msg("Failed to hash link password")
+		return "", problem.New(http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to hash password")
+	}
+
+	return passwordHash, nil
+}
+
+// hashTakenDetail explains an ErrHashTaken, telling apart a hash kept by a
+// link in the trash.
+func hashTakenDetail(hash string, err error) string {
+	if errors.Is(err, ErrHashInTrash) {
+		return "Hash " + strconv.Quote(hash) + " belongs to a deleted link that can still be restored"
+	}
+	return "Hash " + strconv.Quote(hash) + " is already in use"
+}
+
+// createProblem reports a failed insert of link.
+func (handler *LinkHandler) createProblem(link *Link, err error) *problem.Problem {
+	if errors.Is(err, ErrHashTaken) {
+		handler.Logger.Warn().
+			Str("url", link.Url).
+			Str("hash", link.Hash).
+			Msg("Attempted to create link with existing hash")
+		return problem.New(http.StatusConflict, problem.CODE_HASH_TAKEN, hashTakenDetail(link.Hash, err))
+	}
+
+	handler.Logger.Error().
+		Err(err).
+		Str("url", link.Url).
+		Msg("Failed to create link")
+	return problem.New(http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to create link")
+}
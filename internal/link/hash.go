@@ -0,0 +1,194 @@
+package link
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/pkg/hashids"
+	"UrlShortenerBackend/pkg/metrics"
+)
+
+// HashStats describes the allocations made by a HashGenerator since start.
+type HashStats struct {
+	Length     int   `json:"length" example:"10"`
+	Attempts   int64 `json:"attempts" example:"1200"`
+	Collisions int64 `json:"collisions" example:"3"`
+}
+
+// HashGenerator draws random hashes from crypto/rand. It does not check
+// uniqueness itself: callers insert the link and report the outcome with
+// Record, relying on the unique index on live hashes. When the share of
+// collisions over a window of attempts exceeds growThreshold the length
+// grows by one, up to maxLength. The grown length is kept in memory only,
+// so a restart starts again from the configured length.
+type HashGenerator struct {
+	alphabet      []byte
+	maxLength     int
+	growThreshold float64
+
+	mutex            sync.Mutex
+	length           int
+	windowAttempts   int
+	windowCollisions int
+	attempts         int64
+	collisions       int64
+}
+
+func NewHashGenerator(alphabet string, length, maxLength int, growThreshold float64) (*HashGenerator, error) {
+	if alphabet == "" {
+		alphabet = DEFAULT_HASH_ALPHABET
+	}
+	if length <= 0 {
+		length = DEFAULT_HASH_LENGTH
+	}
+	if maxLength <= 0 {
+		maxLength = DEFAULT_HASH_MAX_LENGTH
+	}
+	if growThreshold <= 0 {
+		growThreshold = DEFAULT_HASH_GROW_THRESHOLD
+	}
+
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return nil, fmt.Errorf("hash alphabet must have between 2 and 256 characters, got %d", len(alphabet))
+	}
+	seen := make(map[byte]bool, len(alphabet))
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c <= ' ' || c >= 0x7f || c == '/' || c == '?' || c == '#' || c == '%' {
+			return nil, fmt.Errorf("hash alphabet contains %q, only printable URL path characters are allowed", c)
+		}
+		if seen[c] {
+			return nil, fmt.Errorf("hash alphabet contains %q twice", c)
+		}
+		seen[c] = true
+	}
+	if maxLength < length {
+		return nil, fmt.Errorf("hash max length %d is below the length %d", maxLength, length)
+	}
+	if growThreshold >= 1 {
+		return nil, errors.New("hash grow threshold must be below 1")
+	}
+
+	metrics.HashLength.Set(float64(length))
+
+	return &HashGenerator{
+		alphabet:      []byte(alphabet),
+		maxLength:     maxLength,
+		growThreshold: growThreshold,
+		length:        length,
+	}, nil
+}
+
+// Generate returns a random hash of the current length. Bytes are drawn by
+// rejection sampling so every character of the alphabet is equally likely.
+func (g *HashGenerator) Generate() (string, error) {
+	length := g.Length()
+	size := len(g.alphabet)
+	limit := 256 - 256%size
+
+	hash := make([]byte, 0, length)
+	buf := make([]byte, length+length/2)
+	for len(hash) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("error reading random bytes: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			hash = append(hash, g.alphabet[int(b)%size])
+			if len(hash) == length {
+				break
+			}
+		}
+	}
+
+	return string(hash), nil
+}
+
+// Record accounts for one insert of a generated hash and grows the length
+// once a full window shows too many collisions.
+func (g *HashGenerator) Record(collided bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.attempts++
+	g.windowAttempts++
+	if collided {
+		g.collisions++
+		g.windowCollisions++
+		metrics.HashCollisions.Inc()
+	}
+
+	if g.windowAttempts < HASH_COLLISION_WINDOW {
+		return
+	}
+
+	if float64(g.windowCollisions)/float64(g.windowAttempts) > g.growThreshold {
+		g.grow()
+	}
+	g.windowAttempts = 0
+	g.windowCollisions = 0
+}
+
+// Grow adds a character without waiting for the window, used when a single
+// allocation ran out of attempts. It reports false at the maximum length.
+func (g *HashGenerator) Grow() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.windowAttempts = 0
+	g.windowCollisions = 0
+	return g.grow()
+}
+
+func (g *HashGenerator) grow() bool {
+	if g.length >= g.maxLength {
+		return false
+	}
+	g.length++
+	metrics.HashLength.Set(float64(g.length))
+	return true
+}
+
+func (g *HashGenerator) Length() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.length
+}
+
+func (g *HashGenerator) Stats() HashStats {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return HashStats{
+		Length:     g.length,
+		Attempts:   g.attempts,
+		Collisions: g.collisions,
+	}
+}
+
+// NewSequenceCodec returns the codec encoding link IDs into hashes when the
+// configured mode is "sequence", and nil in "random" mode.
+func NewSequenceCodec(cfg configs.HashConfig) (*hashids.Codec, error) {
+	switch cfg.Mode {
+	case "", HASH_MODE_RANDOM:
+		return nil, nil
+	case HASH_MODE_SEQUENCE:
+	default:
+		return nil, fmt.Errorf("unknown hash mode %q, expected %q or %q", cfg.Mode, HASH_MODE_RANDOM, HASH_MODE_SEQUENCE)
+	}
+
+	if cfg.Salt == "" {
+		return nil, errors.New("hash salt is required in sequence mode")
+	}
+
+	alphabet := cfg.Alphabet
+	if alphabet == "" {
+		alphabet = DEFAULT_HASH_ALPHABET
+	}
+
+	return hashids.New(alphabet, cfg.Salt, cfg.MinLength)
+}
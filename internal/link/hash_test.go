@@ -0,0 +1,172 @@
+package link
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	configs "UrlShortenerBackend/config"
+
+	"github.com/rs/zerolog"
+)
+
+func newTestService(store LinkStore, hashes *HashGenerator) *LinkService {
+	logger := zerolog.Nop()
+	return NewLinkService(&LinkServiceDeps{
+		LinkStore: store,
+		Hashes:    hashes,
+		Config:    &configs.Config{},
+		Logger:    &logger,
+	})
+}
+
+func TestHashGeneratorGenerate(t *testing.T) {
+	hashes, err := NewHashGenerator("abc", 12, 12, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 100 {
+		hash, err := hashes.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hash) != 12 || strings.Trim(hash, "abc") != "" {
+			t.Fatalf("Generate() = %q, want 12 characters of abc", hash)
+		}
+	}
+}
+
+func TestHashGeneratorGrowsAfterCollisionWindow(t *testing.T) {
+	hashes, err := NewHashGenerator("abcdef", 4, 5, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A quiet window keeps the length
+	for range HASH_COLLISION_WINDOW {
+		hashes.Record(false)
+	}
+	if got := hashes.Length(); got != 4 {
+		t.Fatalf("length after a window without collisions = %d, want 4", got)
+	}
+
+	// Collisions only count once the window is full
+	for i := range HASH_COLLISION_WINDOW - 1 {
+		hashes.Record(i%2 == 0)
+	}
+	if got := hashes.Length(); got != 4 {
+		t.Fatalf("length before the window is full = %d, want 4", got)
+	}
+	hashes.Record(true)
+	if got := hashes.Length(); got != 5 {
+		t.Fatalf("length after a window of collisions = %d, want 5", got)
+	}
+
+	// The maximum length caps the growth
+	for range HASH_COLLISION_WINDOW {
+		hashes.Record(true)
+	}
+	if got := hashes.Length(); got != 5 {
+		t.Errorf("length past the maximum = %d, want 5", got)
+	}
+	if hashes.Grow() {
+		t.Error("Grow() reported growth at the maximum length")
+	}
+
+	stats := hashes.Stats()
+	wantCollisions := int64(HASH_COLLISION_WINDOW/2 + 1 + HASH_COLLISION_WINDOW)
+	if stats.Attempts != 3*HASH_COLLISION_WINDOW || stats.Collisions != wantCollisions {
+		t.Errorf("Stats() = %+v, want %d attempts and %d collisions", stats, 3*HASH_COLLISION_WINDOW, wantCollisions)
+	}
+}
+
+// TestCreateLinkGrowsUnderCollisions fills the two hashes of length 1 so
+// that concurrent allocations collide, and expects every link to get a
+// unique, longer hash.
+func TestCreateLinkGrowsUnderCollisions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		hashes, err := NewHashGenerator("ab", 1, 16, 0.01)
+		if err != nil {
+			t.Fatal(err)
+		}
+		service := newTestService(store, hashes)
+
+		const workers, perWorker = 4, 10
+		created := make(chan string, workers*perWorker)
+		var wg sync.WaitGroup
+		for w := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range perWorker {
+					link, err := service.CreateLink(&Link{
+						Url:    "https://example.org/" + strconv.Itoa(w) + "/" + strconv.Itoa(i),
+						UserId: "alice",
+					})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					created <- link.Hash
+				}
+			}()
+		}
+		wg.Wait()
+		close(created)
+
+		seen := map[string]bool{}
+		for hash := range created {
+			if seen[hash] {
+				t.Errorf("hash %q allocated twice", hash)
+			}
+			seen[hash] = true
+		}
+		if len(seen) != workers*perWorker {
+			t.Errorf("created %d links, want %d", len(seen), workers*perWorker)
+		}
+
+		stats := hashes.Stats()
+		if stats.Length <= 1 || stats.Collisions == 0 {
+			t.Errorf("Stats() = %+v, want collisions and a grown length", stats)
+		}
+	})
+}
+
+// BenchmarkCreateLink allocates random hashes from parallel goroutines, the
+// way concurrent POST /api/v1/links requests do.
+func BenchmarkCreateLink(b *testing.B) {
+	for _, backend := range testStores {
+		b.Run(backend.name, func(b *testing.B) {
+			store := backend.open(b)
+
+			hashes, err := NewHashGenerator(DEFAULT_HASH_ALPHABET, DEFAULT_HASH_LENGTH, DEFAULT_HASH_MAX_LENGTH, DEFAULT_HASH_GROW_THRESHOLD)
+			if err != nil {
+				b.Fatal(err)
+			}
+			service := newTestService(store, hashes)
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, err := service.CreateLink(&Link{
+						Url:    "https://example.org/" + strconv.FormatInt(next.Add(1), 10),
+						UserId: "bench",
+					})
+					if err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.StopTimer()
+
+			stats := hashes.Stats()
+			b.ReportMetric(float64(stats.Collisions), "collisions")
+			b.ReportMetric(float64(stats.Length), "length")
+		})
+	}
+}
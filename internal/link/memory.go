@@ -0,0 +1,826 @@
+package link
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"UrlShortenerBackend/internal/click"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MemoryLinkStore keeps links in process. It follows the same rules as the
+// SQL repositories, soft deletes included, and is meant for local runs and
+// tests. Nothing survives a restart. Click events go to clicks.
+type MemoryLinkStore struct {
+	mutex sync.RWMutex
+	// txMutex is held by every write and for the whole of a transaction
+	txMutex sync.Mutex
+	links   map[uint]*Link
+	nextId  uint
+	clicks  click.ClickStore
+}
+
+func NewMemoryLinkStore(clicks click.ClickStore) *MemoryLinkStore {
+	return &MemoryLinkStore{
+		links:  make(map[uint]*Link),
+		nextId: 1,
+		clicks: clicks,
+	}
+}
+
+func (store *MemoryLinkStore) GetAllLinks(filter LinkFilter, sort LinkSort, page, limit int) (*PaginationResult, error) {
+	if page <= 0 {
+		page = DEFAULT_PAGE
+	}
+
+	if limit <= 0 {
+		limit = DEFAULT_LIMIT
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	links := store.filter(func(link *Link) bool {
+		return filter.UserId != "" && filter.matches(link)
+	})
+	sortLinks(links, sort)
+
+	return paginate(links, page, limit), nil
+}
+
+func (store *MemoryLinkStore) GetLinksPage(filter LinkFilter, sort LinkSort, cursor *LinkCursor, limit int) ([]Link, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	links := store.filter(func(link *Link) bool {
+		return filter.UserId != "" && filter.matches(link)
+	})
+	sortLinks(links, sort)
+
+	if cursor == nil {
+		return links[:min(limit, len(links))], nil
+	}
+
+	// Position of the cursor in the listing, found when its link still is
+	at, found := slices.BinarySearchFunc(links, cursor.link(), func(link Link, key *Link) int {
+		return compareLinks(&link, key, sort)
+	})
+	if cursor.Before {
+		return links[max(0, at-limit):at], nil
+	}
+
+	if found {
+		at++
+	}
+	return links[at:min(at+limit, len(links))], nil
+}
+
+func (store *MemoryLinkStore) CountUserLinks(filter LinkFilter) (int64, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	links := store.filter(func(link *Link) bool {
+		return filter.UserId != "" && filter.matches(link)
+	})
+
+	return int64(len(links)), nil
+}
+
+func (store *MemoryLinkStore) SearchAllLinks(filter AdminLinkFilter, page, limit int) (*PaginationResult, error) {
+	if page <= 0 {
+		page = DEFAULT_PAGE
+	}
+
+	if limit <= 0 {
+		limit = DEFAULT_LIMIT
+	}
+
+	query := strings.ToLower(filter.Query)
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return paginate(store.filter(func(link *Link) bool {
+		switch filter.Deleted {
+		case DELETED_INCLUDE:
+		case DELETED_ONLY:
+			if isLive(link) {
+				return false
+			}
+		default:
+			if !isLive(link) {
+				return false
+			}
+		}
+
+		if filter.UserId != "" && link.UserId != filter.UserId {
+			return false
+		}
+
+		if query != "" &&
+			!strings.Contains(strings.ToLower(link.Hash), query) &&
+			!strings.Contains(strings.ToLower(link.Url), query) {
+			return false
+		}
+
+		return true
+	}), page, limit), nil
+}
+
+func (store *MemoryLinkStore) GetLinkByHash(hash string, userId string) (*Link, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	link := store.liveByHash(hash)
+	if link == nil || (userId != "" && link.UserId != userId) {
+		return nil, wrapError(ErrNotFound, gorm.ErrRecordNotFound)
+	}
+
+	return copyLink(link), nil
+}
+
+func (store *MemoryLinkStore) GetLinkById(id uint) (*Link, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	link, ok := store.links[id]
+	if !ok || !isLive(link) {
+		return nil, wrapError(ErrNotFound, gorm.ErrRecordNotFound)
+	}
+
+	return copyLink(link), nil
+}
+
+func (store *MemoryLinkStore) Create(link *Link) (*Link, error) {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.create(link)
+}
+
+func (store *MemoryLinkStore) create(link *Link) (*Link, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if link.Hash == "" {
+		return nil, fmt.Errorf("%w: link has no hash", ErrInvalidInput)
+	}
+	if store.liveByHash(link.Hash) != nil {
+		return nil, ErrHashTaken
+	}
+	if store.trashedByHash(link.Hash, 0) != nil {
+		return nil, ErrHashInTrash
+	}
+
+	if link.UserId == "" {
+		link.UserId = uuid.New().String()
+	}
+
+	now := time.Now().UTC()
+	link.ID = store.nextId
+	link.CreatedAt = now
+	link.UpdatedAt = now
+	store.nextId++
+
+	store.links[link.ID] = copyLink(link)
+
+	return link, nil
+}
+
+// CreateSequential takes the next ID and the hash encode derives from it.
+// Like a database sequence, the ID is used up even when the hash is taken.
+func (store *MemoryLinkStore) CreateSequential(link *Link, encode func(id uint) string) (*Link, error) {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.createSequential(link, encode)
+}
+
+func (store *MemoryLinkStore) createSequential(link *Link, encode func(id uint) string) (*Link, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	id := store.nextId
+	store.nextId++
+
+	hash := encode(id)
+	if store.liveByHash(hash) != nil {
+		return nil, ErrHashTaken
+	}
+	if store.trashedByHash(hash, 0) != nil {
+		return nil, ErrHashInTrash
+	}
+
+	if link.UserId == "" {
+		link.UserId = uuid.New().String()
+	}
+
+	now := time.Now().UTC()
+	link.ID = id
+	link.Hash = hash
+	link.CreatedAt = now
+	link.UpdatedAt = now
+
+	store.links[link.ID] = copyLink(link)
+
+	return link, nil
+}
+
+func (store *MemoryLinkStore) Update(link *Link) (*Link, error) {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.update(link)
+}
+
+func (store *MemoryLinkStore) update(link *Link) (*Link, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	stored, ok := store.links[link.ID]
+	if !ok || !isLive(stored) {
+		return nil, wrapError(ErrNotFound, gorm.ErrRecordNotFound)
+	}
+
+	if existing := store.liveByHash(link.Hash); existing != nil && existing.ID != link.ID {
+		return nil, ErrHashTaken
+	}
+	if store.trashedByHash(link.Hash, link.ID) != nil {
+		return nil, ErrHashInTrash
+	}
+
+	stored.Url = link.Url
+	stored.Hash = link.Hash
+	stored.ExpiresAt = link.ExpiresAt
+	stored.Title = link.Title
+	stored.Tags = append(Tags{}, link.Tags...)
+	stored.setPasswordHash(link.PasswordHash)
+	stored.UpdatedAt = time.Now().UTC()
+
+	return copyLink(stored), nil
+}
+
+func (store *MemoryLinkStore) DeleteExpiredLinks(now time.Time) (int64, error) {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.deleteExpiredLinks(now)
+}
+
+func (store *MemoryLinkStore) deleteExpiredLinks(now time.Time) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var deleted int64
+	for _, link := range store.links {
+		if isLive(link) && link.IsExpired(now) {
+			markDeleted(link)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (store *MemoryLinkStore) CountLinks(now, expiringBefore time.Time) (*LinkCounts, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	counts := &LinkCounts{}
+	for _, link := range store.links {
+		if !isLive(link) || link.IsExpired(now) {
+			continue
+		}
+		counts.Active++
+		if link.ExpiresAt != nil && !link.ExpiresAt.After(expiringBefore) {
+			counts.ExpiringSoon++
+		}
+	}
+
+	return counts, nil
+}
+
+func (store *MemoryLinkStore) RecordClicks(events []*click.ClickEvent) error {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.recordClicks(events)
+}
+
+func (store *MemoryLinkStore) recordClicks(events []*click.ClickEvent) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	// Holding the lock keeps the counts from being read before the events
+	// are saved
+	if err := store.clicks.CreateBatch(events); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if link, ok := store.links[event.LinkId]; ok {
+			link.NumberOfClicks++
+		}
+	}
+
+	return nil
+}
+
+func (store *MemoryLinkStore) DeleteLink(hash string, userId string) error {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.deleteLink(hash, userId)
+}
+
+func (store *MemoryLinkStore) deleteLink(hash string, userId string) error {
+	matches, err := store.CheckUserMatchesLink(hash, userId)
+	if err != nil {
+		return err
+	}
+
+	if !matches {
+		return ErrForbidden
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	link := store.liveByHash(hash)
+	if link == nil || link.UserId != userId {
+		return ErrNotFound
+	}
+
+	markDeleted(link)
+	return nil
+}
+
+func (store *MemoryLinkStore) ForceDeleteLink(id uint) error {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.forceDeleteLink(id)
+}
+
+func (store *MemoryLinkStore) forceDeleteLink(id uint) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.links[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(store.links, id)
+	return nil
+}
+
+func (store *MemoryLinkStore) RestoreLink(id uint) (*Link, error) {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.restoreLink(id)
+}
+
+func (store *MemoryLinkStore) restoreLink(id uint) (*Link, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	link, ok := store.links[id]
+	if !ok || isLive(link) {
+		return nil, ErrNotFound
+	}
+
+	if store.liveByHash(link.Hash) != nil {
+		return nil, ErrHashTaken
+	}
+
+	link.DeletedAt = gorm.DeletedAt{}
+	return copyLink(link), nil
+}
+
+func (store *MemoryLinkStore) GetDeletedLinks(userId string, page, limit int) (*PaginationResult, error) {
+	if page <= 0 {
+		page = DEFAULT_PAGE
+	}
+
+	if limit <= 0 {
+		limit = DEFAULT_LIMIT
+	}
+
+	links, err := store.FindLinks(LinkFilter{UserId: userId, Deleted: DELETED_ONLY}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(links, page, limit), nil
+}
+
+func (store *MemoryLinkStore) GetDeletedLinkByHash(hash, userId string) (*Link, error) {
+	links, err := store.FindLinks(LinkFilter{UserId: userId, Hashes: []string{hash}, Deleted: DELETED_ONLY}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(links) == 0 {
+		return nil, wrapError(ErrNotFound, gorm.ErrRecordNotFound)
+	}
+
+	return &links[0], nil
+}
+
+// PurgeDeletedLinks drops the links deleted at or before deletedBefore.
+// Clicks live in their own store and are left alone, like ForceDeleteLink.
+func (store *MemoryLinkStore) PurgeDeletedLinks(deletedBefore time.Time) (int64, error) {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.purgeDeletedLinks(deletedBefore)
+}
+
+func (store *MemoryLinkStore) purgeDeletedLinks(deletedBefore time.Time) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var purged int64
+	for id, link := range store.links {
+		if !isLive(link) && !link.DeletedAt.Time.After(deletedBefore) {
+			delete(store.links, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (store *MemoryLinkStore) CheckUserExists(userId string) (bool, error) {
+	if userId == "" {
+		return false, nil
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, link := range store.links {
+		if isLive(link) && link.UserId == userId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (store *MemoryLinkStore) CheckUserMatchesLink(hash, userId string) (bool, error) {
+	if hash == "" || userId == "" {
+		return false, fmt.Errorf("%w: hash and userId are required", ErrInvalidInput)
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	link := store.liveByHash(hash)
+	return link != nil && link.UserId == userId, nil
+}
+
+func (store *MemoryLinkStore) ExtendExpiry(userId, hash string, by time.Duration) (int64, error) {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.extendExpiry(userId, hash, by)
+}
+
+func (store *MemoryLinkStore) extendExpiry(userId, hash string, by time.Duration) (int64, error) {
+	if hash != "" {
+		matches, err := store.CheckUserMatchesLink(hash, userId)
+		if err != nil {
+			return 0, err
+		}
+
+		if !matches {
+			return 0, ErrForbidden
+		}
+	} else {
+		exists, err := store.CheckUserExists(userId)
+		if err != nil {
+			return 0, err
+		}
+
+		if !exists {
+			return 0, ErrUserNotFound
+		}
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now().UTC()
+	var updated int64
+	for _, link := range store.links {
+		if !isLive(link) || link.UserId != userId || link.ExpiresAt == nil {
+			continue
+		}
+
+		if hash != "" && link.Hash != hash {
+			continue
+		}
+
+		expiresAt := extendedExpiry(*link.ExpiresAt, now, by)
+		link.ExpiresAt = &expiresAt
+		updated++
+	}
+
+	return updated, nil
+}
+
+func (store *MemoryLinkStore) FindLinks(filter LinkFilter, limit int) ([]Link, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	links := store.filter(func(link *Link) bool {
+		return filter.UserId != "" && filter.matches(link)
+	})
+
+	sort.SliceStable(links, func(i, j int) bool {
+		if !links[i].DeletedAt.Time.Equal(links[j].DeletedAt.Time) {
+			return links[i].DeletedAt.Time.After(links[j].DeletedAt.Time)
+		}
+		return links[i].ID > links[j].ID
+	})
+
+	if limit > 0 && len(links) > limit {
+		links = links[:limit]
+	}
+
+	return links, nil
+}
+
+// StreamLinks copies the matching links up front, fn runs without the lock.
+func (store *MemoryLinkStore) StreamLinks(filter LinkFilter, batchSize int, fn func(links []Link) error) error {
+	store.mutex.RLock()
+	links := store.filter(func(link *Link) bool {
+		return filter.UserId != "" && filter.matches(link)
+	})
+	store.mutex.RUnlock()
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID < links[j].ID
+	})
+
+	for start := 0; start < len(links); start += batchSize {
+		if err := fn(links[start:min(start+batchSize, len(links))]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (store *MemoryLinkStore) DeleteLinks(ids []uint) (int64, error) {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.deleteLinks(ids)
+}
+
+func (store *MemoryLinkStore) deleteLinks(ids []uint) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var deleted int64
+	for _, id := range ids {
+		if link, ok := store.links[id]; ok && isLive(link) {
+			markDeleted(link)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (store *MemoryLinkStore) SetExpiry(id uint, expiresAt time.Time) error {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return store.setExpiry(id, expiresAt)
+}
+
+func (store *MemoryLinkStore) setExpiry(id uint, expiresAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	link, ok := store.links[id]
+	if !ok || !isLive(link) {
+		return ErrNotFound
+	}
+
+	link.ExpiresAt = &expiresAt
+	return nil
+}
+
+// Transaction runs fn against the store and restores the previous links
+// when it fails. Writes outside the transaction wait until it ends, so a
+// rollback only discards what fn did. Like a database sequence, IDs taken
+// inside a failed transaction are not reused.
+func (store *MemoryLinkStore) Transaction(fn func(store LinkStore) error) error {
+	store.txMutex.Lock()
+	defer store.txMutex.Unlock()
+
+	return memoryTx{store}.Transaction(fn)
+}
+
+// memoryTx is the store seen inside a transaction, its writes skip the
+// txMutex held by the transaction.
+type memoryTx struct {
+	*MemoryLinkStore
+}
+
+// Transaction nests in the enclosing one, a failure only restores what fn
+// did, like a savepoint.
+func (tx memoryTx) Transaction(fn func(store LinkStore) error) error {
+	tx.mutex.RLock()
+	snapshot := make(map[uint]*Link, len(tx.links))
+	for id, link := range tx.links {
+		snapshot[id] = copyLink(link)
+	}
+	tx.mutex.RUnlock()
+
+	if err := fn(tx); err != nil {
+		tx.mutex.Lock()
+		tx.links = snapshot
+		tx.mutex.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (tx memoryTx) Create(link *Link) (*Link, error) {
+	return tx.MemoryLinkStore.create(link)
+}
+
+func (tx memoryTx) CreateSequential(link *Link, encode func(id uint) string) (*Link, error) {
+	return tx.MemoryLinkStore.createSequential(link, encode)
+}
+
+func (tx memoryTx) Update(link *Link) (*Link, error) {
+	return tx.MemoryLinkStore.update(link)
+}
+
+func (tx memoryTx) DeleteExpiredLinks(now time.Time) (int64, error) {
+	return tx.MemoryLinkStore.deleteExpiredLinks(now)
+}
+
+func (tx memoryTx) RecordClicks(events []*click.ClickEvent) error {
+	return tx.MemoryLinkStore.recordClicks(events)
+}
+
+func (tx memoryTx) DeleteLink(hash string, userId string) error {
+	return tx.MemoryLinkStore.deleteLink(hash, userId)
+}
+
+func (tx memoryTx) ForceDeleteLink(id uint) error {
+	return tx.MemoryLinkStore.forceDeleteLink(id)
+}
+
+func (tx memoryTx) RestoreLink(id uint) (*Link, error) {
+	return tx.MemoryLinkStore.restoreLink(id)
+}
+
+func (tx memoryTx) PurgeDeletedLinks(deletedBefore time.Time) (int64, error) {
+	return tx.MemoryLinkStore.purgeDeletedLinks(deletedBefore)
+}
+
+func (tx memoryTx) ExtendExpiry(userId, hash string, by time.Duration) (int64, error) {
+	return tx.MemoryLinkStore.extendExpiry(userId, hash, by)
+}
+
+func (tx memoryTx) DeleteLinks(ids []uint) (int64, error) {
+	return tx.MemoryLinkStore.deleteLinks(ids)
+}
+
+func (tx memoryTx) SetExpiry(id uint, expiresAt time.Time) error {
+	return tx.MemoryLinkStore.setExpiry(id, expiresAt)
+}
+
+func (store *MemoryLinkStore) liveByHash(hash string) *Link {
+	for _, link := range store.links {
+		if isLive(link) && link.Hash == hash {
+			return link
+		}
+	}
+
+	return nil
+}
+
+// trashedByHash returns a soft-deleted link other than exceptId holding
+// the hash, which keeps it from being reused until it is purged.
+func (store *MemoryLinkStore) trashedByHash(hash string, exceptId uint) *Link {
+	for id, link := range store.links {
+		if !isLive(link) && link.Hash == hash && id != exceptId {
+			return link
+		}
+	}
+
+	return nil
+}
+
+// filter returns copies of the matching links, newest first.
+func (store *MemoryLinkStore) filter(match func(link *Link) bool) []Link {
+	links := []Link{}
+	for _, link := range store.links {
+		if match(link) {
+			links = append(links, *copyLink(link))
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].CreatedAt.Equal(links[j].CreatedAt) {
+			return links[i].ID > links[j].ID
+		}
+		return links[i].CreatedAt.After(links[j].CreatedAt)
+	})
+
+	return links
+}
+
+// sortLinks orders links like orderClause does in SQL.
+func sortLinks(links []Link, order LinkSort) {
+	if _, ok := sortColumn[order.Field]; !ok {
+		order = LinkSort{Field: LINK_SORT_CREATED_AT, Desc: true}
+	}
+
+	sort.SliceStable(links, func(i, j int) bool {
+		return compareLinks(&links[i], &links[j], order) < 0
+	})
+}
+
+// compareLinks tells whether a comes before b in a listing ordered by
+// order, negative when it does.
+func compareLinks(a, b *Link, order LinkSort) int {
+	var c int
+	switch order.Field {
+	case LINK_SORT_CLICKS:
+		c = cmp.Compare(a.NumberOfClicks, b.NumberOfClicks)
+	case LINK_SORT_EXPIRES_AT:
+		switch {
+		case a.ExpiresAt == nil && b.ExpiresAt == nil:
+		case a.ExpiresAt == nil:
+			return 1
+		case b.ExpiresAt == nil:
+			return -1
+		default:
+			c = a.ExpiresAt.Compare(*b.ExpiresAt)
+		}
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ID, b.ID)
+	}
+
+	if order.Desc {
+		return -c
+	}
+	return c
+}
+
+func paginate(links []Link, page, limit int) *PaginationResult {
+	totalLinks := int64(len(links))
+	totalPages := (len(links) + limit - 1) / limit
+
+	start := min((page-1)*limit, len(links))
+	end := min(start+limit, len(links))
+
+	return &PaginationResult{
+		Links:      links[start:end],
+		TotalLinks: totalLinks,
+		TotalPages: totalPages,
+		Page:       page,
+		Limit:      limit,
+	}
+}
+
+func isLive(link *Link) bool {
+	return !link.DeletedAt.Valid
+}
+
+func markDeleted(link *Link) {
+	link.DeletedAt = gorm.DeletedAt{Time: time.Now().UTC(), Valid: true}
+}
+
+func copyLink(link *Link) *Link {
+	copied := *link
+	copied.Tags = append(Tags{}, link.Tags...)
+	if link.ExpiresAt != nil {
+		expiresAt := *link.ExpiresAt
+		copied.ExpiresAt = &expiresAt
+	}
+
+	return &copied
+}
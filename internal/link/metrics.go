@@ -0,0 +1,85 @@
+package link
+
+import (
+	"time"
+
+	"UrlShortenerBackend/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+var (
+	activeLinksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.NAMESPACE, "", "active_links"),
+		"Live links that have not expired.",
+		nil, nil,
+	)
+	expiringLinksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.NAMESPACE, "", "links_expiring_soon"),
+		"Live links that expire within the configured window.",
+		[]string{"window"}, nil,
+	)
+	cacheLookupsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.NAMESPACE, "redirect_cache", "lookups_total"),
+		"Redirect cache lookups by result.",
+		[]string{"result"}, nil,
+	)
+	cacheEvictionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.NAMESPACE, "redirect_cache", "evictions_total"),
+		"Entries evicted from the redirect cache to make room.",
+		nil, nil,
+	)
+	cacheSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.NAMESPACE, "redirect_cache", "entries"),
+		"Entries currently held by the redirect cache.",
+		nil, nil,
+	)
+)
+
+// LinkCollector reports the link gauges and the redirect cache counters.
+// Link counts are read from the store on every scrape.
+type LinkCollector struct {
+	Store          LinkStore
+	Cache          *RedirectCache
+	Logger         *zerolog.Logger
+	expiringWindow time.Duration
+}
+
+func NewLinkCollector(store LinkStore, cache *RedirectCache, expiringWindow time.Duration, logger *zerolog.Logger) *LinkCollector {
+	if expiringWindow <= 0 {
+		expiringWindow = DEFAULT_EXPIRING_SOON_WINDOW
+	}
+
+	return &LinkCollector{
+		Store:          store,
+		Cache:          cache,
+		Logger:         logger,
+		expiringWindow: expiringWindow,
+	}
+}
+
+func (c *LinkCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeLinksDesc
+	ch <- expiringLinksDesc
+	ch <- cacheLookupsDesc
+	ch <- cacheEvictionsDesc
+	ch <- cacheSizeDesc
+}
+
+func (c *LinkCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	counts, err := c.Store.CountLinks(now, now.Add(c.expiringWindow))
+	if err != nil {
+		c.Logger.Error().Err(err).Msg("Failed to count links for metrics")
+	} else {
+		ch <- prometheus.MustNewConstMetric(activeLinksDesc, prometheus.GaugeValue, float64(counts.Active))
+		ch <- prometheus.MustNewConstMetric(expiringLinksDesc, prometheus.GaugeValue, float64(counts.ExpiringSoon), c.expiringWindow.String())
+	}
+
+	stats := c.Cache.Stats()
+	ch <- prometheus.MustNewConstMetric(cacheLookupsDesc, prometheus.CounterValue, float64(stats.Hits), metrics.REDIRECT_HIT)
+	ch <- prometheus.MustNewConstMetric(cacheLookupsDesc, prometheus.CounterValue, float64(stats.Misses), metrics.REDIRECT_MISS)
+	ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(cacheSizeDesc, prometheus.GaugeValue, float64(stats.Size))
+}
@@ -1,7 +1,9 @@
 package link
 
 import (
-	"math/rand"
+	"database/sql/driver"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -18,22 +20,86 @@ type Link struct {
 	Hash           string         `json:"hash" gorm:"index:,unique,where:deleted_at IS NULL" example:"abc123"`
 	UserId         string         `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
 	NumberOfClicks int64          `json:"number_of_clicks" gorm:"default:0" example:"42"`
-	Lifetime       int64          `json:"lifetime" example:"90"`
+	ExpiresAt      *time.Time     `json:"expires_at" gorm:"index" example:"2025-07-22T00:00:00Z"`
+	Title          string         `json:"title" example:"Spring campaign"`
+	Tags           Tags           `json:"tags" gorm:"type:text;not null;default:''" swaggertype:"array,string" example:"marketing,spring"`
+	// Host of Url, set on save for the domain filter
+	Host string `json:"-" gorm:"not null;default:''"`
+	// Bcrypt hash of the password asked before redirecting, empty for
+	// public links. It never leaves the service.
+	PasswordHash      string `json:"-" gorm:"not null;default:''"`
+	PasswordProtected bool   `json:"password_protected" gorm:"-" example:"false"`
 }
 
-func NewLink(url string) *Link {
-	return &Link{
-		Url:  url,
-		Hash: RandStringRunes(10),
+// BeforeSave keeps Host in step with Url.
+func (link *Link) BeforeSave(tx *gorm.DB) error {
+	link.Host = urlHost(link.Url)
+	return nil
+}
+
+// AfterFind sets PasswordProtected, which is not stored.
+func (link *Link) AfterFind(tx *gorm.DB) error {
+	link.PasswordProtected = link.PasswordHash != ""
+	return nil
+}
+
+// Tags is stored as a comma separated list wrapped in commas (",a,b,"), so a
+// single tag can be matched with LIKE '%,tag,%'.
+type Tags []string
+
+func NormalizeTags(tags []string) Tags {
+	normalized := Tags{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func (tags Tags) Value() (driver.Value, error) {
+	if len(tags) == 0 {
+		return "", nil
 	}
+	return "," + strings.Join(tags, ",") + ",", nil
 }
 
-var letterRunes = []rune("1234567890abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+func (tags *Tags) Scan(value any) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		raw = ""
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("unsupported tags value of type %T", value)
+	}
 
-func RandStringRunes(n int) string {
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+	*tags = Tags{}
+	for _, tag := range strings.Split(raw, ",") {
+		if tag != "" {
+			*tags = append(*tags, tag)
+		}
 	}
-	return string(b)
+	return nil
+}
+
+// NewLink returns a link without a hash, LinkService.CreateLink allocates
+// one when it is stored.
+func NewLink(url string) *Link {
+	return &Link{
+		Url: url,
+	}
+}
+
+// IsExpired reports whether the link expiry has passed. Links without an
+// expiry never expire.
+func (link *Link) IsExpired(now time.Time) bool {
+	return link.ExpiresAt != nil && !link.ExpiresAt.After(now)
 }
@@ -0,0 +1,228 @@
+package link
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/pkg/ratelimit"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrPasswordTooLong = fmt.Errorf("password is longer than %d bytes", MAX_LINK_PASSWORD_BYTES)
+
+// setPasswordHash protects the link with a bcrypt hash, or makes it public
+// again when hash is empty.
+func (link *Link) setPasswordHash(hash string) {
+	link.PasswordHash = hash
+	link.PasswordProtected = hash != ""
+}
+
+// PasswordGate guards password-protected links on the redirect path. It
+// throttles password attempts per link and client address, and per link
+// whatever the address, and signs the cookie that lets a client through once
+// it gave the right password. The cookie is bound to the password hash, so
+// changing the password locks clients out again. Attempts are counted in
+// process, like the redirect cache.
+type PasswordGate struct {
+	secret    []byte
+	cookieTtl time.Duration
+	cost      int
+	clients   *ratelimit.Limiter
+	links     *ratelimit.Limiter
+}
+
+// NewPasswordGate builds the gate from the configuration. Without a cookie
+// secret a random one is drawn, unlocks then end with the process.
+func NewPasswordGate(config configs.PasswordConfig) (*PasswordGate, error) {
+	secret := []byte(config.CookieSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("error generating link password cookie secret: %w", err)
+		}
+	}
+
+	cost := config.BcryptCost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return &PasswordGate{
+		secret:    secret,
+		cookieTtl: config.CookieTtl,
+		cost:      cost,
+		clients:   ratelimit.New(config.MaxAttempts, config.AttemptWindow, PASSWORD_ATTEMPTS_CACHE_SIZE),
+		links:     ratelimit.New(config.LinkMaxAttempts, config.AttemptWindow, PASSWORD_ATTEMPTS_CACHE_SIZE),
+	}, nil
+}
+
+// HashPassword returns the bcrypt hash stored on the link.
+func (g *PasswordGate) HashPassword(password string) (string, error) {
+	if len(password) > MAX_LINK_PASSWORD_BYTES {
+		return "", ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), g.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the hash of the target.
+func (g *PasswordGate) CheckPassword(target *RedirectTarget, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(target.PasswordHash), []byte(password)) == nil
+}
+
+func attemptKey(linkId uint, ip string) string {
+	return strconv.FormatUint(uint64(linkId), 10) + "|" + ip
+}
+
+func linkKey(linkId uint) string {
+	return strconv.FormatUint(uint64(linkId), 10)
+}
+
+// Attempt reserves a password attempt of the client at ip for the link
+// before the password is compared, so parallel requests cannot go over the
+// limits. It returns 0 when the attempt may go ahead, otherwise how long to
+// wait before the next one.
+func (g *PasswordGate) Attempt(linkId uint, ip string) time.Duration {
+	if wait := g.clients.Take(attemptKey(linkId, ip)); wait > 0 {
+		return wait
+	}
+
+	if wait := g.links.Take(linkKey(linkId)); wait > 0 {
+		g.clients.Release(attemptKey(linkId, ip))
+		return wait
+	}
+
+	return 0
+}
+
+// Release gives back an attempt that did not compare a password.
+func (g *PasswordGate) Release(linkId uint, ip string) {
+	g.clients.Release(attemptKey(linkId, ip))
+	g.links.Release(linkKey(linkId))
+}
+
+// Succeeded forgets the attempts of the client at ip, and does not count
+// the right password against the link.
+func (g *PasswordGate) Succeeded(linkId uint, ip string) {
+	g.clients.Reset(attemptKey(linkId, ip))
+	g.links.Release(linkKey(linkId))
+}
+
+// signature binds a cookie to the link, its current password and expiry.
+func (g *PasswordGate) signature(target *RedirectTarget, expires int64) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	binary.Write(mac, binary.BigEndian, uint64(target.LinkId))
+	binary.Write(mac, binary.BigEndian, expires)
+	mac.Write([]byte(target.PasswordHash))
+	return mac.Sum(nil)
+}
+
+// Unlock sets the cookie that skips the password prompt of the target for
+// the cookie TTL. The cookie only goes back to the path of the link, and
+// only over HTTPS when secure is set.
+func (g *PasswordGate) Unlock(w http.ResponseWriter, r *http.Request, target *RedirectTarget, secure bool) {
+	expires := time.Now().Add(g.cookieTtl)
+	value := strconv.FormatInt(expires.Unix(), 10) + "." +
+		base64.RawURLEncoding.EncodeToString(g.signature(target, expires.Unix()))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     PASSWORD_COOKIE_NAME,
+		Value:    value,
+		Path:     r.URL.EscapedPath(),
+		Expires:  expires,
+		MaxAge:   int(g.cookieTtl.Seconds()),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Unlocked reports whether the request carries a valid, unexpired cookie
+// for the target.
+func (g *PasswordGate) Unlocked(r *http.Request, target *RedirectTarget) bool {
+	cookie, err := r.Cookie(PASSWORD_COOKIE_NAME)
+	if err != nil {
+		return false
+	}
+
+	expiresStr, signatureStr, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(signatureStr)
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(signature, g.signature(target, expires)) == 1
+}
+
+var passwordForm = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+<style>
+body { font-family: system-ui, sans-serif; display: flex; justify-content: center; margin-top: 15vh; }
+form { display: flex; flex-direction: column; gap: .75rem; width: 18rem; }
+input, button { font: inherit; padding: .5rem; }
+.error { color: #b00020; margin: 0; }
+</style>
+</head>
+<body>
+<form method="post" action="{{.Action}}">
+<h1>Password required</h1>
+<label for="password">This link is protected, enter its password to continue.</label>
+{{if .Error}}<p class="error" role="alert">{{.Error}}</p>{{end}}
+<input id="password" name="password" type="password" autocomplete="current-password" autofocus required>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+// writePasswordForm serves the password prompt of a protected link, with
+// message shown above the field when it is not empty.
+func writePasswordForm(w http.ResponseWriter, r *http.Request, status int, message string) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+
+	return passwordForm.Execute(w, struct {
+		Action string
+		Error  string
+	}{
+		Action: r.URL.EscapedPath(),
+		Error:  message,
+	})
+}
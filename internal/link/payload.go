@@ -1,19 +1,139 @@
 package link
 
+import (
+	"encoding/json"
+	"time"
+
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/pkg/problem"
+)
+
 type LinkCreateRequest struct {
-	Url    string `json:"url" validate:"required,url" example:"https://example.com"`
-	UserId string `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Hash   string `json:"hash" example:"custom123"`
+	Url       string     `json:"url" validate:"required,url" example:"https://example.com"`
+	Hash      string     `json:"hash" example:"custom123"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2025-07-22T00:00:00Z"`
+	Title     string     `json:"title" validate:"max=255" example:"Spring campaign"`
+	Tags      []string   `json:"tags" validate:"max=20,dive,min=1,max=50,excludes=0x2C" example:"marketing,spring"`
+	// Password asked before redirecting, none when empty
+	Password string `json:"password" validate:"omitempty,min=4,max=72" example:"s3cret"`
+}
+
+// LinkBatchItemResult is the outcome of one link of a batch, Link when it
+// was created and Error otherwise
+type LinkBatchItemResult struct {
+	Index  int              `json:"index" example:"0"`
+	Status int              `json:"status" example:"201"`
+	Link   *Link            `json:"link,omitempty"`
+	Error  *problem.Problem `json:"error,omitempty"`
+}
+
+type LinkBatchCreateResponse struct {
+	Mode    string                `json:"mode" example:"atomic"`
+	Created int                   `json:"created" example:"2"`
+	Failed  int                   `json:"failed" example:"0"`
+	Results []LinkBatchItemResult `json:"results"`
+}
+
+// LinkBulkFilter selects links by tag, creation range and destination
+// domain. At least one criterion is required
+type LinkBulkFilter struct {
+	Tag         string     `json:"tag" validate:"omitempty,max=50" example:"marketing"`
+	CreatedFrom *time.Time `json:"created_from" example:"2025-04-01T00:00:00Z"`
+	CreatedTo   *time.Time `json:"created_to" example:"2025-05-01T00:00:00Z"`
+	Domain      string     `json:"domain" validate:"omitempty,max=255" example:"example.com"`
+}
+
+// LinkBulkRequest selects the links of a bulk operation, either by hash or
+// with a filter
+type LinkBulkRequest struct {
+	Hashes []string        `json:"hashes" validate:"omitempty,dive,required" example:"abc123,def456"`
+	Filter *LinkBulkFilter `json:"filter"`
+}
+
+type LinkBulkExtendRequest struct {
+	LinkBulkRequest
+	Days  int `json:"days" validate:"gte=0" example:"7"`
+	Hours int `json:"hours" validate:"gte=0" example:"12"`
+}
+
+type LinkBulkResponse struct {
+	Action    string        `json:"action" example:"delete"`
+	Matched   int           `json:"matched" example:"3"`
+	Succeeded int           `json:"succeeded" example:"2"`
+	Failed    int           `json:"failed" example:"1"`
+	Results   []BulkOutcome `json:"results"`
+}
+
+// LinkExportRow is a link as exported. Its fields are read back by import,
+// except created_at and number_of_clicks
+type LinkExportRow struct {
+	Hash           string     `json:"hash" example:"abc123"`
+	Url            string     `json:"url" example:"https://example.com"`
+	Title          string     `json:"title" example:"Spring campaign"`
+	Tags           []string   `json:"tags" example:"marketing,spring"`
+	ExpiresAt      *time.Time `json:"expires_at" example:"2025-07-22T00:00:00Z"`
+	CreatedAt      time.Time  `json:"created_at" example:"2025-04-23T00:00:00Z"`
+	NumberOfClicks int64      `json:"number_of_clicks" example:"42"`
+}
+
+// LinkImportRowResult is the outcome of one row of an import. Row counts
+// the links of the file from 1, without the CSV header. OriginalHash is the
+// generated code of the row when the link got a new one
+type LinkImportRowResult struct {
+	Row          int              `json:"row" example:"1"`
+	Status       int              `json:"status" example:"201"`
+	Hash         string           `json:"hash,omitempty" example:"abc123"`
+	OriginalHash string           `json:"original_hash,omitempty" example:"x7Kp2Qa"`
+	Error        *problem.Problem `json:"error,omitempty"`
+}
+
+type LinkImportResponse struct {
+	Format   string                `json:"format" example:"csv"`
+	Imported int                   `json:"imported" example:"2"`
+	Failed   int                   `json:"failed" example:"1"`
+	Results  []LinkImportRowResult `json:"results"`
+}
+
+// LinkUpdateRequest only changes the fields that are present in the body. An
+// empty password removes the protection, a null expires_at the expiry
+type LinkUpdateRequest struct {
+	Url       *string      `json:"url" validate:"omitempty,url" example:"https://example.org"`
+	Hash      *string      `json:"hash" example:"renamed123"`
+	ExpiresAt NullableTime `json:"expires_at" swaggertype:"string" format:"date-time" extensions:"x-nullable" example:"2025-08-22T00:00:00Z"`
+	Title     *string      `json:"title" validate:"omitempty,max=255" example:"Summer campaign"`
+	Tags      *[]string    `json:"tags" validate:"omitempty,max=20,dive,min=1,max=50,excludes=0x2C" example:"marketing,summer"`
+	Password  *string      `json:"password" validate:"omitempty,max=72" example:"n3w-s3cret"`
+}
+
+// NullableTime tells a field missing from the body apart from a null one:
+// Set is true whenever the field is present, Time is nil when it is null.
+type NullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (t *NullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if string(data) == "null" {
+		t.Time = nil
+		return nil
+	}
+
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	t.Time = &value
+
+	return nil
 }
 
 type LinkDeleteRequest struct {
-	Hash   string `json:"hash" validate:"required" example:"abc123"`
-	UserId string `json:"user_id" validate:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Hash string `json:"hash" validate:"required" example:"abc123"`
 }
 
 type GetLinkRequest struct {
-	UserId string `json:"user_id" validate:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Hash   string `json:"hash" validate:"required" example:"abc123"`
+	Hash string `json:"hash" validate:"required" example:"abc123"`
 }
 
 type GetAllLinksResponse struct {
@@ -24,12 +144,60 @@ type GetAllLinksResponse struct {
 	Limit      int    `json:"limit" example:"10"`
 }
 
+// GetLinksPageResponse is a page of the listing in cursor mode. A cursor is
+// left out when there is nothing more in its direction, TotalLinks unless
+// a count was asked for
+type GetLinksPageResponse struct {
+	Links          []Link `json:"links"`
+	Limit          int    `json:"limit" example:"10"`
+	NextCursor     string `json:"next_cursor,omitempty" example:"eyJmIjoiY3JlYXRlZF9hdCIsImQiOnRydWUsImkiOjQyfQ"`
+	PrevCursor     string `json:"prev_cursor,omitempty" example:"eyJmIjoiY3JlYXRlZF9hdCIsImQiOnRydWUsImIiOnRydWUsImkiOjMzfQ"`
+	TotalLinks     *int64 `json:"total_links,omitempty" example:"42"`
+	TotalEstimated bool   `json:"total_estimated,omitempty" example:"true"`
+}
+
+// TrashedLink is a deleted link, removed for good at PurgeAt. PurgeAt is
+// left out when the trash is never purged
+type TrashedLink struct {
+	Link
+	PurgeAt *time.Time `json:"purge_at,omitempty" example:"2025-05-23T00:00:00Z"`
+}
+
+type GetTrashResponse struct {
+	Links      []TrashedLink `json:"links"`
+	TotalPages int           `json:"total_pages" example:"1"`
+	TotalLinks int64         `json:"total_links" example:"3"`
+	Page       int           `json:"page" example:"1"`
+	Limit      int           `json:"limit" example:"10"`
+}
+
+type GetClicksResponse struct {
+	Events      []click.ClickEvent `json:"events"`
+	TotalPages  int                `json:"total_pages" example:"3"`
+	TotalEvents int64              `json:"total_events" example:"120"`
+	Page        int                `json:"page" example:"1"`
+	Limit       int                `json:"limit" example:"50"`
+}
+
+type LinkStatsResponse struct {
+	Hash           string             `json:"hash" example:"abc123"`
+	Interval       string             `json:"interval" example:"day"`
+	From           time.Time          `json:"from" example:"2025-04-16T00:00:00Z"`
+	To             time.Time          `json:"to" example:"2025-04-23T00:00:00Z"`
+	TotalClicks    int64              `json:"total_clicks" example:"120"`
+	UniqueVisitors int64              `json:"unique_visitors" example:"87"`
+	Buckets        []click.Bucket     `json:"buckets"`
+	TopReferrers   []click.CountEntry `json:"top_referrers"`
+	TopUserAgents  []click.CountEntry `json:"top_user_agents"`
+}
+
 type GetAllLinksRequest struct {
-	UserId string `json:"user_id" validate:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Page   int    `json:"page" example:"1"`
-	Limit  int    `json:"limit" example:"10"`
+	Page  int `json:"page" example:"1"`
+	Limit int `json:"limit" example:"10"`
 }
 
 type AddDaysRequest struct {
-	UserId string `json:"user_id" validate:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Hash  string `json:"hash" example:"abc123"`
+	Days  int    `json:"days" validate:"gte=0" example:"7"`
+	Hours int    `json:"hours" validate:"gte=0" example:"12"`
 }
@@ -0,0 +1,186 @@
+package link
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	configs "UrlShortenerBackend/config"
+)
+
+// HashPolicyError explains why a custom hash was refused. Rule is one of
+// charset, min_length, max_length, reserved or profanity.
+type HashPolicyError struct {
+	Rule    string
+	Message string
+}
+
+func (e *HashPolicyError) Error() string {
+	return e.Message
+}
+
+// HashPolicy checks hashes chosen by users. Reserved words and blocked words
+// are matched case-insensitively whatever the case folding: a reserved word
+// matches the whole hash, a blocked word any part of it.
+type HashPolicy struct {
+	charset   *regexp.Regexp
+	charsetIn string
+	minLength int
+	maxLength int
+	caseFold  string
+	blocked   []string
+
+	mutex    sync.RWMutex
+	reserved map[string]bool
+}
+
+func NewHashPolicy(cfg configs.VanityConfig) (*HashPolicy, error) {
+	charsetIn := cfg.Charset
+	if charsetIn == "" {
+		charsetIn = DEFAULT_VANITY_CHARSET
+	}
+	minLength := cfg.MinLength
+	if minLength <= 0 {
+		minLength = DEFAULT_VANITY_MIN_LENGTH
+	}
+	maxLength := cfg.MaxLength
+	if maxLength <= 0 {
+		maxLength = DEFAULT_VANITY_MAX_LENGTH
+	}
+	caseFold := cfg.CaseFold
+	if caseFold == "" {
+		caseFold = CASE_FOLD_PRESERVE
+	}
+
+	charset, err := regexp.Compile("^[" + charsetIn + "]+$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid vanity charset %q: %w", charsetIn, err)
+	}
+	if charset.MatchString("/") || charset.MatchString("?") || charset.MatchString("#") {
+		return nil, fmt.Errorf("vanity charset %q must not allow '/', '?' or '#'", charsetIn)
+	}
+	if maxLength < minLength {
+		return nil, fmt.Errorf("vanity max length %d is below the min length %d", maxLength, minLength)
+	}
+	if caseFold != CASE_FOLD_PRESERVE && caseFold != CASE_FOLD_LOWER {
+		return nil, fmt.Errorf("unknown case folding %q, expected %q or %q", caseFold, CASE_FOLD_PRESERVE, CASE_FOLD_LOWER)
+	}
+
+	policy := &HashPolicy{
+		charset:   charset,
+		charsetIn: charsetIn,
+		minLength: minLength,
+		maxLength: maxLength,
+		caseFold:  caseFold,
+		reserved:  map[string]bool{},
+	}
+	policy.Reserve(cfg.Reserved...)
+
+	if cfg.ProfanityFile != "" {
+		policy.blocked, err = readWordList(cfg.ProfanityFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// Reserve refuses words as hashes, typically the first path segments of
+// the registered routes.
+func (p *HashPolicy) Reserve(words ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			p.reserved[word] = true
+		}
+	}
+}
+
+// Normalize applies the case folding to a custom hash and checks it
+// against the policy. The error is a *HashPolicyError.
+func (p *HashPolicy) Normalize(hash string) (string, error) {
+	if p.caseFold == CASE_FOLD_LOWER {
+		hash = strings.ToLower(hash)
+	}
+
+	length := len([]rune(hash))
+	if length < p.minLength {
+		return "", &HashPolicyError{
+			Rule:    "min_length",
+			Message: fmt.Sprintf("hash must be at least %d characters long", p.minLength),
+		}
+	}
+	if length > p.maxLength {
+		return "", &HashPolicyError{
+			Rule:    "max_length",
+			Message: fmt.Sprintf("hash must be at most %d characters long", p.maxLength),
+		}
+	}
+	if !p.charset.MatchString(hash) {
+		return "", &HashPolicyError{
+			Rule:    "charset",
+			Message: fmt.Sprintf("hash may only contain the characters [%s]", p.charsetIn),
+		}
+	}
+
+	folded := strings.ToLower(hash)
+
+	p.mutex.RLock()
+	reserved := p.reserved[folded]
+	p.mutex.RUnlock()
+	if reserved {
+		return "", &HashPolicyError{
+			Rule:    "reserved",
+			Message: fmt.Sprintf("hash %q is reserved", hash),
+		}
+	}
+
+	for _, word := range p.blocked {
+		if strings.Contains(folded, word) {
+			return "", &HashPolicyError{
+				Rule:    "profanity",
+				Message: "hash contains a blocked word",
+			}
+		}
+	}
+
+	return hash, nil
+}
+
+// FoldsCase reports whether custom hashes are stored in lower case, so a
+// redirect that misses can retry with the lowered hash.
+func (p *HashPolicy) FoldsCase() bool {
+	return p.caseFold == CASE_FOLD_LOWER
+}
+
+// readWordList reads one word per line, ignoring blank lines and lines
+// starting with #.
+func readWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening word list: %w", err)
+	}
+	defer file.Close()
+
+	words := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading word list %s: %w", path, err)
+	}
+
+	return words, nil
+}
@@ -0,0 +1,98 @@
+package link
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	configs "UrlShortenerBackend/config"
+)
+
+func TestHashPolicyNormalize(t *testing.T) {
+	profanity := filepath.Join(t.TempDir(), "profanity.txt")
+	if err := os.WriteFile(profanity, []byte("# blocked words\nbadword\n\n  Rude \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		caseFold string
+		hash     string
+		want     string
+		rule     string
+	}{
+		{"valid", CASE_FOLD_PRESERVE, "My-Link_1", "My-Link_1", ""},
+		{"case preserved", CASE_FOLD_PRESERVE, "ABC", "ABC", ""},
+		{"case folded", CASE_FOLD_LOWER, "ABC", "abc", ""},
+		{"too short", CASE_FOLD_PRESERVE, "ab", "", "min_length"},
+		{"too long", CASE_FOLD_PRESERVE, "abcdefghijk", "", "max_length"},
+		{"multibyte length", CASE_FOLD_PRESERVE, "ééé", "", "charset"},
+		{"slash", CASE_FOLD_PRESERVE, "a/b/c", "", "charset"},
+		{"space", CASE_FOLD_PRESERVE, "a b c", "", "charset"},
+		{"reserved", CASE_FOLD_PRESERVE, "swagger", "", "reserved"},
+		{"reserved any case", CASE_FOLD_PRESERVE, "SwAgGeR", "", "reserved"},
+		{"reserved at runtime", CASE_FOLD_PRESERVE, "api", "", "reserved"},
+		{"reserved prefix is fine", CASE_FOLD_PRESERVE, "swaggers", "swaggers", ""},
+		{"blocked word", CASE_FOLD_PRESERVE, "xbadwordx", "", "profanity"},
+		{"blocked word any case", CASE_FOLD_PRESERVE, "xxRUDExx", "", "profanity"},
+		{"comment is not a word", CASE_FOLD_PRESERVE, "blocked", "blocked", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewHashPolicy(configs.VanityConfig{
+				Charset:       "A-Za-z0-9_-",
+				MinLength:     3,
+				MaxLength:     10,
+				CaseFold:      tt.caseFold,
+				Reserved:      []string{"swagger"},
+				ProfanityFile: profanity,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy.Reserve("API")
+
+			got, err := policy.Normalize(tt.hash)
+			if tt.rule == "" {
+				if err != nil {
+					t.Fatalf("Normalize(%q) error = %v", tt.hash, err)
+				}
+				if got != tt.want {
+					t.Errorf("Normalize(%q) = %q, want %q", tt.hash, got, tt.want)
+				}
+				return
+			}
+
+			var policyErr *HashPolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Normalize(%q) error = %v, want a *HashPolicyError", tt.hash, err)
+			}
+			if policyErr.Rule != tt.rule {
+				t.Errorf("Normalize(%q) rule = %q, want %q", tt.hash, policyErr.Rule, tt.rule)
+			}
+		})
+	}
+}
+
+func TestNewHashPolicyRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  configs.VanityConfig
+	}{
+		{"invalid charset", configs.VanityConfig{Charset: "z-a"}},
+		{"charset allows slash", configs.VanityConfig{Charset: "a-z/"}},
+		{"max below min", configs.VanityConfig{MinLength: 5, MaxLength: 4}},
+		{"unknown case folding", configs.VanityConfig{CaseFold: "upper"}},
+		{"missing profanity file", configs.VanityConfig{ProfanityFile: "/nonexistent/words.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHashPolicy(tt.cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
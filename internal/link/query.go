@@ -0,0 +1,139 @@
+package link
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"UrlShortenerBackend/internal/click"
+)
+
+// parseTime accepts either a full RFC 3339 timestamp or a plain date.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339 or YYYY-MM-DD", value)
+	}
+
+	return t.UTC(), nil
+}
+
+func parseStatsQuery(r *http.Request) (*click.StatsQuery, error) {
+	values := r.URL.Query()
+
+	interval, err := click.ParseInterval(values.Get("interval"))
+	if err != nil {
+		return nil, err
+	}
+
+	to := time.Now().UTC()
+	if toStr := values.Get("to"); toStr != "" {
+		to, err = parseTime(toStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	from := to.AddDate(0, 0, -click.DEFAULT_STATS_RANGE_DAYS)
+	if fromStr := values.Get("from"); fromStr != "" {
+		from, err = parseTime(fromStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !from.Before(to) {
+		return nil, errors.New("from must be before to")
+	}
+
+	if to.Sub(from)/interval.Duration() > click.MAX_STATS_BUCKETS {
+		return nil, fmt.Errorf("range is too large for interval %s, at most %d buckets are allowed", interval, click.MAX_STATS_BUCKETS)
+	}
+
+	top := click.DEFAULT_STATS_TOP
+	if topStr := values.Get("top"); topStr != "" {
+		if t, err := strconv.Atoi(topStr); err == nil && t > 0 {
+			top = min(t, click.MAX_STATS_TOP)
+		}
+	}
+
+	return &click.StatsQuery{
+		Interval: interval,
+		From:     from,
+		To:       to,
+		Top:      top,
+	}, nil
+}
+
+// parseListQuery reads the filters and the order of the link listing.
+func parseListQuery(r *http.Request, userId string) (*LinkFilter, *LinkSort, error) {
+	values := r.URL.Query()
+
+	filter := &LinkFilter{
+		UserId:     userId,
+		HashPrefix: values.Get("hash_prefix"),
+		Tag:        strings.ToLower(strings.TrimSpace(values.Get("tag"))),
+		Domain:     strings.ToLower(strings.TrimSpace(values.Get("domain"))),
+		Search:     strings.TrimSpace(values.Get("q")),
+	}
+
+	if minClicks := values.Get("min_clicks"); minClicks != "" {
+		clicks, err := strconv.ParseInt(minClicks, 10, 64)
+		if err != nil || clicks < 0 {
+			return nil, nil, fmt.Errorf("invalid min_clicks %q, expected a non-negative number", minClicks)
+		}
+		filter.MinClicks = clicks
+	}
+
+	ranges := []struct {
+		name string
+		dest **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"expires_from", &filter.ExpiresFrom},
+		{"expires_to", &filter.ExpiresTo},
+	}
+	for _, bound := range ranges {
+		value := values.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", bound.name, err)
+		}
+		*bound.dest = &t
+	}
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return nil, nil, errors.New("created_from must be before created_to")
+	}
+	if filter.ExpiresFrom != nil && filter.ExpiresTo != nil && !filter.ExpiresFrom.Before(*filter.ExpiresTo) {
+		return nil, nil, errors.New("expires_from must be before expires_to")
+	}
+
+	sort := &LinkSort{Field: LINK_SORT_CREATED_AT, Desc: true}
+	if field := values.Get("sort"); field != "" {
+		if _, ok := sortColumn[field]; !ok {
+			return nil, nil, fmt.Errorf("invalid sort %q, expected created_at, expires_at or clicks", field)
+		}
+		sort.Field = field
+	}
+	switch order := values.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		sort.Desc = false
+	default:
+		return nil, nil, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+
+	return filter, sort, nil
+}
@@ -0,0 +1,148 @@
+package link
+
+import (
+	"time"
+
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/pkg/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+// ClickRecorder buffers redirects in memory and persists them in batches so
+// the redirect path never waits on the database.
+type ClickRecorder struct {
+	LinkStore     LinkStore
+	Logger        *zerolog.Logger
+	flushInterval time.Duration
+	batchSize     int
+	maxPending    int
+	events        chan *click.ClickEvent
+	stopChan      chan struct{}
+	doneChan      chan struct{}
+}
+
+func NewClickRecorder(linkStore LinkStore, flushInterval time.Duration, batchSize, bufferSize int, logger *zerolog.Logger) *ClickRecorder {
+	if flushInterval <= 0 {
+		flushInterval = DEFAULT_CLICK_FLUSH_INTERVAL
+	}
+
+	if batchSize <= 0 {
+		batchSize = DEFAULT_CLICK_BATCH_SIZE
+	}
+
+	if bufferSize <= 0 {
+		bufferSize = DEFAULT_CLICK_BUFFER_SIZE
+	}
+
+	return &ClickRecorder{
+		LinkStore:     linkStore,
+		Logger:        logger,
+		flushInterval: flushInterval,
+		batchSize:     batchSize,
+		maxPending:    bufferSize,
+		events:        make(chan *click.ClickEvent, bufferSize),
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+	}
+}
+
+// Record queues a click without blocking. When the buffer is full the click
+// is dropped rather than slowing the redirect down.
+func (r *ClickRecorder) Record(event *click.ClickEvent) {
+	select {
+	case r.events <- event:
+	default:
+		r.Logger.Warn().Str("hash", event.Hash).Msg("Click buffer is full, dropping click")
+	}
+}
+
+func (r *ClickRecorder) Start() {
+	go r.run()
+}
+
+// Stop flushes every pending click and returns once they are persisted, or
+// once a batch kept failing.
+func (r *ClickRecorder) Stop() {
+	close(r.stopChan)
+	<-r.doneChan
+}
+
+func (r *ClickRecorder) run() {
+	defer close(r.doneChan)
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*click.ClickEvent, 0, r.batchSize)
+	// A failed batch waits for the next tick instead of retrying on every click
+	retrying := false
+
+	for {
+		select {
+		case event := <-r.events:
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize && !retrying {
+				batch, retrying = r.flush(batch)
+			}
+		case <-ticker.C:
+			batch, retrying = r.flush(batch)
+		case <-r.stopChan:
+			for {
+				select {
+				case event := <-r.events:
+					batch = append(batch, event)
+					if len(batch) >= r.batchSize {
+						batch = r.flushOnStop(batch)
+					}
+				default:
+					r.flushOnStop(batch)
+					r.Logger.Info().Msg("Click recorder stopped")
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush persists the batch and hands back the slice to fill next. A failed
+// batch is handed back whole to be retried, unless it has grown to the size
+// of the buffer in which case it is dropped.
+func (r *ClickRecorder) flush(batch []*click.ClickEvent) ([]*click.ClickEvent, bool) {
+	if len(batch) == 0 {
+		return batch, false
+	}
+
+	start := time.Now()
+	err := r.LinkStore.RecordClicks(batch)
+	metrics.ObserveJob(JOB_CLICK_FLUSH, start, err)
+	if err == nil {
+		r.Logger.Debug().Int("events", len(batch)).Msg("Flushed click batch")
+		return batch[:0], false
+	}
+
+	if len(batch) >= r.maxPending {
+		r.Logger.Error().Err(err).Int("events", len(batch)).Msg("Failed to flush click events, dropping them")
+		return batch[:0], false
+	}
+
+	r.Logger.Warn().Err(err).Int("events", len(batch)).Msg("Failed to flush click events, retrying on the next tick")
+	return batch, true
+}
+
+// flushOnStop retries a failed batch a few times before giving up, as there
+// is no next tick to carry it to.
+func (r *ClickRecorder) flushOnStop(batch []*click.ClickEvent) []*click.ClickEvent {
+	for attempt := 1; ; attempt++ {
+		var failed bool
+		batch, failed = r.flush(batch)
+		if !failed {
+			return batch
+		}
+		if attempt == CLICK_FLUSH_STOP_ATTEMPTS {
+			r.Logger.Error().Int("events", len(batch)).Msg("Giving up on click events at shutdown")
+			return batch[:0]
+		}
+		time.Sleep(CLICK_FLUSH_RETRY_DELAY)
+	}
+}
@@ -0,0 +1,92 @@
+package link
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"UrlShortenerBackend/internal/click"
+
+	"github.com/rs/zerolog"
+)
+
+// flakyStore fails the first RecordClicks calls and counts what it records.
+type flakyStore struct {
+	LinkStore
+	mutex    sync.Mutex
+	failures int
+	calls    int
+	recorded int
+}
+
+func (s *flakyStore) RecordClicks(events []*click.ClickEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.calls++
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("database is down")
+	}
+	s.recorded += len(events)
+	return nil
+}
+
+func (s *flakyStore) counts() (int, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.calls, s.recorded
+}
+
+func TestClickRecorderRetriesOnStop(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		want     int
+	}{
+		{"persisted", 0, 3},
+		{"retried", CLICK_FLUSH_STOP_ATTEMPTS - 1, 3},
+		{"given up", CLICK_FLUSH_STOP_ATTEMPTS, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := zerolog.Nop()
+			store := &flakyStore{failures: tt.failures}
+			recorder := NewClickRecorder(store, time.Hour, 10, 10, &logger)
+			recorder.Start()
+
+			for range 3 {
+				recorder.Record(&click.ClickEvent{Hash: "abc"})
+			}
+			recorder.Stop()
+
+			if _, recorded := store.counts(); recorded != tt.want {
+				t.Errorf("recorded %d clicks, want %d", recorded, tt.want)
+			}
+		})
+	}
+}
+
+func TestClickRecorderCarriesFailedBatch(t *testing.T) {
+	logger := zerolog.Nop()
+	store := &flakyStore{failures: 1}
+	recorder := NewClickRecorder(store, 10*time.Millisecond, 2, 10, &logger)
+	recorder.Start()
+	defer recorder.Stop()
+
+	// The first batch fails and is retried with the later click on a tick
+	for range 3 {
+		recorder.Record(&click.ClickEvent{Hash: "abc"})
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, recorded := store.counts(); recorded == 3 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	_, recorded := store.counts()
+	t.Fatalf("recorded %d clicks, want 3", recorded)
+}
@@ -1,12 +1,18 @@
 package link
 
 import (
+	"UrlShortenerBackend/internal/click"
 	"UrlShortenerBackend/pkg/db"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaginationResult struct {
@@ -17,18 +23,43 @@ type PaginationResult struct {
 	Limit      int
 }
 
+// LinkCounts feeds the link gauges of the metrics endpoint.
+type LinkCounts struct {
+	Active       int64
+	ExpiringSoon int64
+}
+
+type AdminLinkFilter struct {
+	Query   string
+	UserId  string
+	Deleted string
+}
+
 type LinkRepository struct {
 	Database *db.Db
+	// likeOperator is the case-insensitive LIKE of the SQL dialect
+	likeOperator string
 }
 
 func NewLinkRepository(database *db.Db) *LinkRepository {
 	return &LinkRepository{
-		Database: database,
+		Database:     database,
+		likeOperator: "ILIKE",
 	}
 }
 
-func (repo *LinkRepository) GetAllLinks(userId string, page, limit int) (*PaginationResult, error) {
-	if userId == "" {
+// GetAllLinks lists the links of filter.UserId that match filter, a page at
+// a time in the given order.
+func (repo *LinkRepository) GetAllLinks(filter LinkFilter, sort LinkSort, page, limit int) (*PaginationResult, error) {
+	if page <= 0 {
+		page = DEFAULT_PAGE
+	}
+
+	if limit <= 0 {
+		limit = DEFAULT_LIMIT
+	}
+
+	if filter.UserId == "" {
 		return &PaginationResult{
 			Links:      []Link{},
 			TotalLinks: 0,
@@ -38,6 +69,98 @@ func (repo *LinkRepository) GetAllLinks(userId string, page, limit int) (*Pagina
 		}, nil
 	}
 
+	query := repo.filterQuery(filter)
+
+	var totalLinks int64
+	if err := query.Session(&gorm.Session{}).Count(&totalLinks).Error; err != nil {
+		return nil, err
+	}
+
+	links := []Link{}
+	if totalLinks > 0 {
+		offset := (page - 1) * limit
+		if err := query.Order(orderClause(sort, false)).Offset(offset).Limit(limit).Find(&links).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	totalPages := (int(totalLinks) + limit - 1) / limit
+
+	return &PaginationResult{
+		Links:      links,
+		TotalLinks: totalLinks,
+		TotalPages: totalPages,
+		Page:       page,
+		Limit:      limit,
+	}, nil
+}
+
+// GetLinksPage lists at most limit links of filter.UserId that match
+// filter, in the given order, after the cursor or before it when
+// cursor.Before is set. The links come in listing order either way.
+func (repo *LinkRepository) GetLinksPage(filter LinkFilter, sort LinkSort, cursor *LinkCursor, limit int) ([]Link, error) {
+	links := []Link{}
+	if filter.UserId == "" {
+		return links, nil
+	}
+
+	query := repo.filterQuery(filter)
+	backwards := cursor != nil && cursor.Before
+	if cursor != nil {
+		condition, args := keysetCondition(sort, cursor)
+		query = query.Where(condition, args...)
+	}
+
+	if err := query.Order(orderClause(sort, backwards)).Limit(limit).Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	if backwards {
+		slices.Reverse(links)
+	}
+
+	return links, nil
+}
+
+// CountUserLinks counts the links of filter.UserId that match filter.
+func (repo *LinkRepository) CountUserLinks(filter LinkFilter) (int64, error) {
+	if filter.UserId == "" {
+		return 0, nil
+	}
+
+	var count int64
+	err := repo.filterQuery(filter).Count(&count).Error
+	return count, err
+}
+
+// orderClause turns sort into an ORDER BY clause, created_at DESC when the
+// field is unknown. reverse flips the whole order, links without expiry
+// included, to read a listing backwards.
+func orderClause(sort LinkSort, reverse bool) string {
+	column, ok := sortColumn[sort.Field]
+	if !ok {
+		column, sort.Desc = "created_at", true
+	}
+
+	direction := "ASC"
+	if sort.Desc != reverse {
+		direction = "DESC"
+	}
+
+	nulls := ""
+	if column == "expires_at" && reverse {
+		nulls = " NULLS FIRST"
+	} else if column == "expires_at" {
+		nulls = " NULLS LAST"
+	}
+
+	return column + " " + direction + nulls + ", id " + direction
+}
+
+// SearchAllLinks lists links across all users for operators. Query matches
+// the hash or the destination URL, deleted decides whether soft-deleted
+// links are excluded, included or the only ones returned.
+func (repo *LinkRepository) SearchAllLinks(filter AdminLinkFilter, page, limit int) (*PaginationResult, error) {
 	if page <= 0 {
 		page = DEFAULT_PAGE
 	}
@@ -46,28 +169,36 @@ func (repo *LinkRepository) GetAllLinks(userId string, page, limit int) (*Pagina
 		limit = DEFAULT_LIMIT
 	}
 
-	offset := (page - 1) * limit
+	query := repo.Database.DB.Model(&Link{}).Unscoped()
 
-	var links []Link
-	result := repo.Database.DB.Table("links").Where("deleted_at IS NULL AND user_id = ?", userId).Order("created_at DESC").Offset(offset).Limit(limit).Scan(&links)
-	if result.Error != nil {
-		return nil, result.Error
+	switch filter.Deleted {
+	case DELETED_INCLUDE:
+	case DELETED_ONLY:
+		query = query.Where("deleted_at IS NOT NULL")
+	default:
+		query = query.Where("deleted_at IS NULL")
 	}
 
-	if len(links) == 0 {
-		return &PaginationResult{
-			Links:      []Link{},
-			TotalLinks: 0,
-			TotalPages: 0,
-			Page:       page,
-			Limit:      limit,
-		}, nil
+	if filter.UserId != "" {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+
+	if filter.Query != "" {
+		pattern := "%" + filter.Query + "%"
+		query = query.Where(fmt.Sprintf("hash %[1]s ? OR url %[1]s ?", repo.likeOperator), pattern, pattern)
 	}
 
 	var totalLinks int64
-	countResult := repo.Database.DB.Table("links").Where("deleted_at IS NULL AND user_id = ?", userId).Count(&totalLinks)
-	if countResult.Error != nil {
-		return nil, countResult.Error
+	if err := query.Session(&gorm.Session{}).Count(&totalLinks).Error; err != nil {
+		return nil, err
+	}
+
+	links := []Link{}
+	if totalLinks > 0 {
+		offset := (page - 1) * limit
+		if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&links).Error; err != nil {
+			return nil, err
+		}
 	}
 
 	totalPages := (int(totalLinks) + limit - 1) / limit
@@ -86,7 +217,7 @@ func (repo *LinkRepository) GetLinkByHash(hash string, userId string) (*Link, er
 		var link Link
 		result := repo.Database.DB.Where("hash = ? AND user_id = ? AND deleted_at IS NULL", hash, userId).First(&link)
 		if result.Error != nil {
-			return nil, result.Error
+			return nil, translateError(result.Error, "error getting link")
 		}
 		return &link, nil
 	}
@@ -94,108 +225,178 @@ func (repo *LinkRepository) GetLinkByHash(hash string, userId string) (*Link, er
 	var link Link
 	result := repo.Database.DB.Where("hash = ? AND deleted_at IS NULL", hash).First(&link)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateError(result.Error, "error getting link")
 	}
 
 	return &link, nil
 }
 
+// Transaction runs fn with a repository bound to one database transaction,
+// committed when fn returns nil. Writes inside it that use transactions of
+// their own, like Create, run in savepoints, so a conflicting insert can be
+// retried without aborting the whole transaction.
+func (repo *LinkRepository) Transaction(fn func(store LinkStore) error) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(repo.withTx(tx))
+	})
+}
+
+func (repo *LinkRepository) withTx(tx *gorm.DB) *LinkRepository {
+	return &LinkRepository{
+		Database:     &db.Db{DB: tx},
+		likeOperator: repo.likeOperator,
+	}
+}
+
+// GetLinkById returns the live link with the primary key id.
+func (repo *LinkRepository) GetLinkById(id uint) (*Link, error) {
+	var link Link
+	result := repo.Database.DB.Where("id = ? AND deleted_at IS NULL", id).First(&link)
+	if result.Error != nil {
+		return nil, translateError(result.Error, "error getting link")
+	}
+
+	return &link, nil
+}
+
+// Create inserts link with the hash it carries. Availability is not checked
+// beforehand, the partial unique index on live hashes rejects a taken hash
+// with ErrHashTaken, which also holds for concurrent inserts. A hash held by
+// a link in the trash is refused with ErrHashInTrash.
 func (repo *LinkRepository) Create(link *Link) (*Link, error) {
 	if link.Hash == "" {
-		link.Hash = RandStringRunes(10)
+		return nil, fmt.Errorf("%w: link has no hash", ErrInvalidInput)
+	}
 
-		for {
-			var existingLink Link
-			result := repo.Database.DB.Where("hash = ? AND deleted_at IS NULL", link.Hash).First(&existingLink)
+	if link.UserId == "" {
+		link.UserId = uuid.New().String()
+	}
 
-			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				break
-			}
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkTrashedHash(tx, link.Hash, 0); err != nil {
+			return err
+		}
 
-			if result.Error != nil {
-				return nil, fmt.Errorf("error checking hash uniqueness: %w", result.Error)
-			}
+		return tx.Create(link).Error
+	})
+	if err != nil {
+		return nil, translateError(err, "error creating link")
+	}
 
-			link.Hash = RandStringRunes(10)
-		}
-	} else {
-		var existingLink Link
-		result := repo.Database.DB.Where("hash = ? AND deleted_at IS NULL", link.Hash).First(&existingLink)
+	return link, nil
+}
 
-		if result.Error == nil {
-			return nil, errors.New("hash already exists")
+// CreateSequential inserts link without a hash and then sets the hash
+// derived from the ID the database assigned, in one transaction. Live
+// hashes are unique and NULL until then, so concurrent inserts never
+// conflict with each other; ErrHashTaken only means a custom hash, live or
+// in the trash, already holds the code.
+func (repo *LinkRepository) CreateSequential(link *Link, encode func(id uint) string) (*Link, error) {
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("hash").Create(link).Error; err != nil {
+			return err
 		}
 
-		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("error checking hash existence: %w", result.Error)
+		link.Hash = encode(link.ID)
+
+		if err := checkTrashedHash(tx, link.Hash, link.ID); err != nil {
+			return err
 		}
-	}
 
-	if link.UserId == "" {
-		link.UserId = uuid.New().String()
+		return tx.Model(link).Update("hash", link.Hash).Error
+	})
+	if err != nil {
+		link.ID = 0
+		link.Hash = ""
+		return nil, translateError(err, "error creating link")
+	}
 
-		for {
-			var existingLink Link
-			result := repo.Database.DB.Where("user_id = ?", link.UserId).First(&existingLink)
+	return link, nil
+}
 
-			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				break
-			}
+// Update saves the editable fields of an existing link. A renamed hash must
+// not be used by another link, live or still in the trash.
+func (repo *LinkRepository) Update(link *Link) (*Link, error) {
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var existingLink Link
+		result := tx.Where("hash = ? AND id <> ? AND deleted_at IS NULL", link.Hash, link.ID).First(&existingLink)
 
-			if result.Error != nil {
-				return nil, fmt.Errorf("error checking user ID uniqueness: %w", result.Error)
-			}
+		if result.Error == nil {
+			return ErrHashTaken
+		}
 
-			link.UserId = uuid.New().String()
+		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("error checking hash existence: %w", result.Error)
 		}
-	}
 
-	var deletedLink Link
-	deletedResult := repo.Database.DB.Unscoped().Where("hash = ? AND deleted_at IS NOT NULL", link.Hash).First(&deletedLink)
+		if err := checkTrashedHash(tx, link.Hash, link.ID); err != nil {
+			return err
+		}
 
-	if deletedResult.Error == nil {
-		deleteErr := repo.Database.DB.Unscoped().Delete(&deletedLink).Error
-		if deleteErr != nil {
-			return nil, fmt.Errorf("error removing previously deleted link with same hash: %w", deleteErr)
+		err := tx.Model(link).Select("url", "host", "hash", "expires_at", "title", "tags", "password_hash").Updates(link).Error
+		if err != nil {
+			return translateError(err, "error updating link")
 		}
-	}
 
-	result := repo.Database.DB.Create(link)
-	if result.Error != nil {
-		return nil, fmt.Errorf("error creating link: %w", result.Error)
+		return translateError(tx.First(link, link.ID).Error, "error reading updated link")
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return link, nil
 }
 
-func (repo *LinkRepository) DecrementLifetimeForAllLinks() error {
-
-	result := repo.Database.DB.Exec("UPDATE links SET lifetime = lifetime - 1 WHERE lifetime > 0 AND deleted_at IS NULL")
-	return result.Error
+// DeleteExpiredLinks soft-deletes every link whose expiry has passed and
+// returns how many were removed.
+func (repo *LinkRepository) DeleteExpiredLinks(now time.Time) (int64, error) {
+	result := repo.Database.DB.Where("expires_at IS NOT NULL AND expires_at <= ?", now).Delete(&Link{})
+	return result.RowsAffected, result.Error
 }
 
-func (repo *LinkRepository) DeleteExpiredLinks() error {
-
-	var expiredLinks []Link
-	result := repo.Database.DB.Where("lifetime = 0 AND deleted_at IS NULL").Find(&expiredLinks)
-	if result.Error != nil {
-		return result.Error
+// CountLinks counts the live, unexpired links and how many of them expire
+// before expiringBefore.
+func (repo *LinkRepository) CountLinks(now, expiringBefore time.Time) (*LinkCounts, error) {
+	var counts LinkCounts
+	err := repo.Database.DB.Model(&Link{}).
+		Select("COUNT(*) AS active, COALESCE(SUM(CASE WHEN expires_at IS NOT NULL AND expires_at <= ? THEN 1 ELSE 0 END), 0) AS expiring_soon", expiringBefore).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
 	}
 
-	count := len(expiredLinks)
-	if count == 0 {
+	return &counts, nil
+}
+
+// RecordClicks saves the click events and adds them to the click counts of
+// their links in a single transaction, so a link never counts a click whose
+// event was lost. Counts are incremented in SQL so concurrent flushes never
+// lose clicks.
+func (repo *LinkRepository) RecordClicks(events []*click.ClickEvent) error {
+	if len(events) == 0 {
 		return nil
 	}
 
-	result = repo.Database.DB.Delete(&expiredLinks)
+	counts := make(map[uint]int64)
+	for _, event := range events {
+		counts[event.LinkId]++
+	}
 
-	return result.Error
-}
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(events, len(events)).Error; err != nil {
+			return err
+		}
 
-func (repo *LinkRepository) IncrementClicksCount(link *Link) error {
-	link.NumberOfClicks++
-	result := repo.Database.DB.Model(link).Update("number_of_clicks", link.NumberOfClicks)
-	return result.Error
+		for linkId, count := range counts {
+			err := tx.Exec("UPDATE links SET number_of_clicks = number_of_clicks + ? WHERE id = ?", count, linkId).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 func (repo *LinkRepository) DeleteLink(hash string, userId string) error {
@@ -205,16 +406,13 @@ func (repo *LinkRepository) DeleteLink(hash string, userId string) error {
 	}
 
 	if !matches {
-		return errors.New("link not found or user does not have permission")
+		return ErrForbidden
 	}
 
 	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
 		var link Link
 		if err := tx.Where("hash = ? AND user_id = ? AND deleted_at IS NULL", hash, userId).First(&link).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("link not found or already deleted")
-			}
-			return err
+			return translateError(err, "error getting link")
 		}
 
 		if err := tx.Delete(&link).Error; err != nil {
@@ -225,6 +423,271 @@ func (repo *LinkRepository) DeleteLink(hash string, userId string) error {
 	})
 }
 
+// FindLinks returns the links of filter.UserId that match filter, most
+// recently deleted first, then newest first. A limit above 0 caps how many
+// are returned.
+func (repo *LinkRepository) FindLinks(filter LinkFilter, limit int) ([]Link, error) {
+	if filter.UserId == "" {
+		return []Link{}, nil
+	}
+
+	query := repo.filterQuery(filter).Order("deleted_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var links []Link
+	if err := query.Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("error finding links: %w", err)
+	}
+
+	return links, nil
+}
+
+// StreamLinks hands the links of filter.UserId that match filter to fn in
+// batches of at most batchSize, in ID order, so that all of a user's links
+// can be walked without holding them in memory. An error of fn stops the
+// walk and is returned.
+func (repo *LinkRepository) StreamLinks(filter LinkFilter, batchSize int, fn func(links []Link) error) error {
+	if filter.UserId == "" {
+		return nil
+	}
+
+	var batch []Link
+	result := repo.filterQuery(filter).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		// batch must stay untouched, GORM reads the last ID from it
+		return fn(slices.Clone(batch))
+	})
+
+	return result.Error
+}
+
+// filterQuery narrows the query down with every criterion of filter.
+// Domains are matched on the host column, which links keep in step with
+// their URL on save.
+func (repo *LinkRepository) filterQuery(filter LinkFilter) *gorm.DB {
+	query := repo.Database.DB.Model(&Link{}).Unscoped().Where("user_id = ?", filter.UserId)
+
+	switch filter.Deleted {
+	case DELETED_INCLUDE:
+	case DELETED_ONLY:
+		query = query.Where("deleted_at IS NOT NULL")
+	default:
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	if len(filter.Hashes) > 0 {
+		query = query.Where("hash IN ?", filter.Hashes)
+	}
+	if filter.HashPrefix != "" {
+		// LIKE can use an index, substr makes the match case-sensitive on
+		// SQLite too
+		query = query.Where("hash LIKE ? ESCAPE '\\' AND substr(hash, 1, ?) = ?",
+			escapeLike(filter.HashPrefix)+"%", utf8.RuneCountInString(filter.HashPrefix), filter.HashPrefix)
+	}
+	if filter.Tag != "" {
+		query = query.Where("tags LIKE ? ESCAPE '\\'", "%,"+escapeLike(filter.Tag)+",%")
+	}
+	if filter.Domain != "" {
+		domain := strings.TrimSuffix(strings.ToLower(filter.Domain), ".")
+		query = query.Where("(host = ? OR host LIKE ? ESCAPE '\\')", domain, "%."+escapeLike(domain))
+	}
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		query = query.Where(fmt.Sprintf("(url %[1]s ? ESCAPE '\\' OR title %[1]s ? ESCAPE '\\')", repo.likeOperator), pattern, pattern)
+	}
+	if filter.MinClicks > 0 {
+		query = query.Where("number_of_clicks >= ?", filter.MinClicks)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+	if filter.ExpiresFrom != nil {
+		query = query.Where("expires_at >= ?", *filter.ExpiresFrom)
+	}
+	if filter.ExpiresTo != nil {
+		query = query.Where("expires_at < ?", *filter.ExpiresTo)
+	}
+
+	return query
+}
+
+// DeleteLinks soft-deletes the live links with the given IDs.
+func (repo *LinkRepository) DeleteLinks(ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := repo.Database.DB.Where("id IN ?", ids).Delete(&Link{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("error deleting links: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// SetExpiry sets the expiry of the live link with the primary key id.
+func (repo *LinkRepository) SetExpiry(id uint, expiresAt time.Time) error {
+	result := repo.Database.DB.Model(&Link{}).Where("id = ?", id).Update("expires_at", expiresAt)
+	if result.Error != nil {
+		return fmt.Errorf("error setting link expiry: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// checkTrashedHash returns ErrHashInTrash when a soft-deleted link other
+// than exceptId holds hash. Such links can be restored until they are
+// purged, so their hash is not reused before. The live link holding hash,
+// if any, is locked first: a delete of it waits for the transaction, and a
+// delete that committed meanwhile is seen by the check.
+func checkTrashedHash(tx *gorm.DB, hash string, exceptId uint) error {
+	var live []uint
+	err := tx.Model(&Link{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("hash = ?", hash).
+		Pluck("id", &live).Error
+	if err != nil {
+		return fmt.Errorf("error locking link with same hash: %w", err)
+	}
+
+	var count int64
+	err = tx.Unscoped().Model(&Link{}).
+		Where("hash = ? AND id <> ? AND deleted_at IS NOT NULL", hash, exceptId).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("error checking deleted links with same hash: %w", err)
+	}
+
+	if count > 0 {
+		return ErrHashInTrash
+	}
+
+	return nil
+}
+
+// GetDeletedLinks lists the trash of a user, most recently deleted first.
+func (repo *LinkRepository) GetDeletedLinks(userId string, page, limit int) (*PaginationResult, error) {
+	if page <= 0 {
+		page = DEFAULT_PAGE
+	}
+
+	if limit <= 0 {
+		limit = DEFAULT_LIMIT
+	}
+
+	query := repo.filterQuery(LinkFilter{UserId: userId, Deleted: DELETED_ONLY})
+
+	var totalLinks int64
+	if err := query.Session(&gorm.Session{}).Count(&totalLinks).Error; err != nil {
+		return nil, err
+	}
+
+	links := []Link{}
+	if totalLinks > 0 {
+		offset := (page - 1) * limit
+		if err := query.Order("deleted_at DESC, id DESC").Offset(offset).Limit(limit).Find(&links).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &PaginationResult{
+		Links:      links,
+		TotalLinks: totalLinks,
+		TotalPages: (int(totalLinks) + limit - 1) / limit,
+		Page:       page,
+		Limit:      limit,
+	}, nil
+}
+
+// GetDeletedLinkByHash finds the link of userId in the trash holding hash,
+// the most recently deleted one if there are several.
+func (repo *LinkRepository) GetDeletedLinkByHash(hash, userId string) (*Link, error) {
+	var link Link
+	err := repo.Database.DB.Unscoped().
+		Where("hash = ? AND user_id = ? AND deleted_at IS NOT NULL", hash, userId).
+		Order("deleted_at DESC, id DESC").
+		First(&link).Error
+	if err != nil {
+		return nil, translateError(err, "error getting deleted link")
+	}
+
+	return &link, nil
+}
+
+// PurgeDeletedLinks permanently removes the links deleted at or before
+// deletedBefore, together with their click history.
+func (repo *LinkRepository) PurgeDeletedLinks(deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		trashed := tx.Unscoped().Model(&Link{}).Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at <= ?", deletedBefore)
+		if err := tx.Where("link_id IN (?)", trashed).Delete(&click.ClickEvent{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", deletedBefore).Delete(&Link{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+
+	return purged, err
+}
+
+// ForceDeleteLink permanently removes a link, deleted or not, together with
+// its click history.
+func (repo *LinkRepository) ForceDeleteLink(id uint) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Delete(&Link{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		return tx.Where("link_id = ?", id).Delete(&click.ClickEvent{}).Error
+	})
+}
+
+// RestoreLink brings a soft-deleted link back, as long as no live link has
+// taken its hash in the meantime.
+func (repo *LinkRepository) RestoreLink(id uint) (*Link, error) {
+	var link Link
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&link)
+		if result.Error != nil {
+			return translateError(result.Error, "error getting deleted link")
+		}
+
+		var existingLink Link
+		result = tx.Where("hash = ? AND deleted_at IS NULL", link.Hash).First(&existingLink)
+		if result.Error == nil {
+			return ErrHashTaken
+		}
+
+		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("error checking hash existence: %w", result.Error)
+		}
+
+		err := tx.Unscoped().Model(&link).Update("deleted_at", nil).Error
+		return translateError(err, "error restoring link")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	link.DeletedAt = gorm.DeletedAt{}
+	return &link, nil
+}
+
 func (repo *LinkRepository) CheckUserExists(userId string) (bool, error) {
 	if userId == "" {
 		return false, nil
@@ -241,7 +704,7 @@ func (repo *LinkRepository) CheckUserExists(userId string) (bool, error) {
 
 func (repo *LinkRepository) CheckUserMatchesLink(hash, userId string) (bool, error) {
 	if hash == "" || userId == "" {
-		return false, errors.New("hash and userId are required")
+		return false, fmt.Errorf("%w: hash and userId are required", ErrInvalidInput)
 	}
 
 	var link Link
@@ -258,20 +721,40 @@ func (repo *LinkRepository) CheckUserMatchesLink(hash, userId string) (bool, err
 	return true, nil
 }
 
-func (repo *LinkRepository) AddDaysToAllUserLinks(userId string) (int64, error) {
+// ExtendExpiry pushes the expiry of a single link, or of every link of the
+// user when hash is empty, by the given duration. Links that already expired
+// but are not purged yet are extended from now. Links without an expiry are
+// left untouched.
+func (repo *LinkRepository) ExtendExpiry(userId, hash string, by time.Duration) (int64, error) {
+	if hash != "" {
+		matches, err := repo.CheckUserMatchesLink(hash, userId)
+		if err != nil {
+			return 0, err
+		}
 
-	exists, err := repo.CheckUserExists(userId)
-	if err != nil {
-		return 0, fmt.Errorf("error checking user existence: %w", err)
+		if !matches {
+			return 0, ErrForbidden
+		}
+	} else {
+		exists, err := repo.CheckUserExists(userId)
+		if err != nil {
+			return 0, fmt.Errorf("error checking user existence: %w", err)
+		}
+
+		if !exists {
+			return 0, ErrUserNotFound
+		}
 	}
 
-	if !exists {
-		return 0, errors.New("user not found")
+	query := repo.Database.DB.Model(&Link{}).
+		Where("user_id = ? AND expires_at IS NOT NULL", userId)
+	if hash != "" {
+		query = query.Where("hash = ?", hash)
 	}
 
-	result := repo.Database.DB.Exec("UPDATE links SET lifetime = lifetime + 1 WHERE user_id = ? AND deleted_at IS NULL", userId)
+	result := query.Update("expires_at", gorm.Expr("GREATEST(expires_at, NOW()) + make_interval(secs => ?)", by.Seconds()))
 	if result.Error != nil {
-		return 0, fmt.Errorf("error updating links lifetime: %w", result.Error)
+		return 0, fmt.Errorf("error extending links expiry: %w", result.Error)
 	}
 
 	return result.RowsAffected, nil
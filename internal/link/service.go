@@ -1,65 +1,585 @@
 package link
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/internal/urlpolicy"
+	"UrlShortenerBackend/pkg/cache"
+	"UrlShortenerBackend/pkg/hashids"
+	"UrlShortenerBackend/pkg/metrics"
+
 	"github.com/rs/zerolog"
 )
 
+type LinkServiceDeps struct {
+	LinkStore LinkStore
+	Hashes    *HashGenerator
+	Codes     *hashids.Codec
+	Policy    *HashPolicy
+	UrlPolicy *urlpolicy.Policy
+	Passwords *PasswordGate
+	Config    *configs.Config
+	Logger    *zerolog.Logger
+}
+
 type LinkService struct {
-	Repository *LinkRepository
-	Logger     *zerolog.Logger
-	stopChan   chan struct{}
+	Repository          LinkStore
+	Recorder            *ClickRecorder
+	Cache               *RedirectCache
+	Hashes              *HashGenerator
+	Codes               *hashids.Codec
+	Policy              *HashPolicy
+	UrlPolicy           *urlpolicy.Policy
+	Passwords           *PasswordGate
+	Logger              *zerolog.Logger
+	expiryCheckInterval time.Duration
+	counts              *cache.LRU[string, int64]
+	countTtl            time.Duration
+	trashRetention      time.Duration
+	lifetimeMutex       sync.Mutex
+	purgeMutex          sync.Mutex
+	stopChan            chan struct{}
+	heartbeat           atomic.Int64
 }
 
-func NewLinkService(repository *LinkRepository, logger *zerolog.Logger) *LinkService {
+func NewLinkService(deps *LinkServiceDeps) *LinkService {
+	recorder := NewClickRecorder(
+		deps.LinkStore,
+		deps.Config.Clicks.FlushInterval,
+		deps.Config.Clicks.BatchSize,
+		deps.Config.Clicks.BufferSize,
+		deps.Logger,
+	)
+
+	expiryCheckInterval := deps.Config.Links.ExpiryCheckInterval
+	if expiryCheckInterval <= 0 {
+		expiryCheckInterval = DEFAULT_EXPIRY_CHECK_INTERVAL
+	}
+
+	counts := cache.NewLRU[string, int64](deps.Config.Links.CountCacheSize)
+
+	cache := NewRedirectCache(
+		deps.LinkStore,
+		deps.Codes,
+		deps.Config.Cache.Size,
+		deps.Config.Cache.Ttl,
+		deps.Config.Cache.NegativeTtl,
+	)
+
 	return &LinkService{
-		Repository: repository,
-		Logger:     logger,
-		stopChan:   make(chan struct{}),
+		Repository:          deps.LinkStore,
+		Recorder:            recorder,
+		Cache:               cache,
+		Hashes:              deps.Hashes,
+		Codes:               deps.Codes,
+		Policy:              deps.Policy,
+		UrlPolicy:           deps.UrlPolicy,
+		Passwords:           deps.Passwords,
+		Logger:              deps.Logger,
+		expiryCheckInterval: expiryCheckInterval,
+		counts:              counts,
+		countTtl:            deps.Config.Links.CountCacheTtl,
+		trashRetention:      deps.Config.Links.TrashRetention,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// CreateLink stores link. A hash chosen by the user is inserted as is and
+// fails with ErrHashTaken when it is in use. In sequence mode the hash
+// encodes the ID of the new link. Otherwise a hash is generated and drawn
+// again for as long as the insert collides. After MAX_HASH_ATTEMPTS
+// collisions in a row hashes grow by one character, the allocation only
+// fails once they cannot grow any further.
+func (s *LinkService) CreateLink(link *Link) (*Link, error) {
+	return s.createLink(s.Repository, link)
+}
+
+// CreateLinks stores a batch of links. In atomic mode they are inserted in
+// one transaction and the first failure rolls all of them back, the other
+// links then fail with ErrBatchAborted. Otherwise every link is stored on
+// its own. The returned errors are indexed like links, nil when created.
+func (s *LinkService) CreateLinks(links []*Link, atomic bool) []error {
+	errs := make([]error, len(links))
+
+	if !atomic {
+		for i, link := range links {
+			_, errs[i] = s.CreateLink(link)
+		}
+		return errs
+	}
+
+	failed := -1
+	err := s.Repository.Transaction(func(store LinkStore) error {
+		for i, link := range links {
+			if _, err := s.createLink(store, link); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return errs
+	}
+
+	for i := range links {
+		if i == failed {
+			errs[i] = err
+		} else if failed >= 0 {
+			errs[i] = ErrBatchAborted
+		} else {
+			// The commit itself failed
+			errs[i] = err
+		}
+	}
+
+	return errs
+}
+
+// BulkOutcome is what a bulk operation did to one link. ExpiresAt is the
+// new expiry of an extended link.
+type BulkOutcome struct {
+	Hash      string     `json:"hash" example:"abc123"`
+	Status    string     `json:"status" example:"deleted"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2025-08-22T00:00:00Z"`
+}
+
+// Succeeded reports whether the action was applied to the link.
+func (o *BulkOutcome) Succeeded() bool {
+	switch o.Status {
+	case BULK_STATUS_DELETED, BULK_STATUS_RESTORED, BULK_STATUS_EXTENDED:
+		return true
+	}
+	return false
+}
+
+// BulkApply deletes, restores or extends the links selected by filter in
+// one transaction, and fails with ErrBulkTooLarge when more than limit links
+// match. Extended links expire by later, counted from now for those that
+// already expired. Restore looks at deleted links and brings back the most
+// recently deleted one of each hash. Requested hashes that match nothing
+// are reported as not found, links whose hash was reused as taken and links
+// without expiry as not extendable, none of which aborts the others.
+func (s *LinkService) BulkApply(action string, filter LinkFilter, by time.Duration, limit int) ([]BulkOutcome, error) {
+	filter.Deleted = DELETED_EXCLUDE
+	if action == BULK_ACTION_RESTORE {
+		filter.Deleted = DELETED_ONLY
+	}
+
+	var outcomes []BulkOutcome
+	err := s.Repository.Transaction(func(store LinkStore) error {
+		// One more than allowed is enough to refuse the request. A hash is
+		// in the trash at most once, so rows and hashes hardly differ
+		links, err := store.FindLinks(filter, limit+1)
+		if err != nil {
+			return err
+		}
+
+		links = latestPerHash(links)
+		if len(links) > limit {
+			return fmt.Errorf("%w: more than %d links match", ErrBulkTooLarge, limit)
+		}
+
+		outcomes, err = applyBulk(store, action, links, by)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.Succeeded() {
+			s.Cache.Invalidate(outcome.Hash)
+		}
+	}
+
+	return append(outcomes, missingHashes(filter.Hashes, outcomes)...), nil
+}
+
+func applyBulk(store LinkStore, action string, links []Link, by time.Duration) ([]BulkOutcome, error) {
+	outcomes := make([]BulkOutcome, 0, len(links))
+
+	switch action {
+	case BULK_ACTION_DELETE:
+		ids := make([]uint, len(links))
+		for i, link := range links {
+			ids[i] = link.ID
+			outcomes = append(outcomes, BulkOutcome{Hash: link.Hash, Status: BULK_STATUS_DELETED})
+		}
+		if _, err := store.DeleteLinks(ids); err != nil {
+			return nil, err
+		}
+
+	case BULK_ACTION_RESTORE:
+		for _, link := range links {
+			outcome := BulkOutcome{Hash: link.Hash, Status: BULK_STATUS_RESTORED}
+			_, err := store.RestoreLink(link.ID)
+			if errors.Is(err, ErrHashTaken) {
+				outcome.Status = BULK_STATUS_HASH_TAKEN
+			} else if err != nil {
+				return nil, err
+			}
+			outcomes = append(outcomes, outcome)
+		}
+
+	case BULK_ACTION_EXTEND:
+		now := time.Now().UTC()
+		for _, link := range links {
+			if link.ExpiresAt == nil {
+				outcomes = append(outcomes, BulkOutcome{Hash: link.Hash, Status: BULK_STATUS_NO_EXPIRY})
+				continue
+			}
+
+			expiresAt := extendedExpiry(*link.ExpiresAt, now, by)
+			if err := store.SetExpiry(link.ID, expiresAt); err != nil {
+				return nil, err
+			}
+			outcomes = append(outcomes, BulkOutcome{Hash: link.Hash, Status: BULK_STATUS_EXTENDED, ExpiresAt: &expiresAt})
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unknown bulk action %q", ErrInvalidInput, action)
+	}
+
+	return outcomes, nil
+}
+
+// latestPerHash keeps the first link of each hash, the most recently
+// deleted one in the order of FindLinks.
+func latestPerHash(links []Link) []Link {
+	seen := make(map[string]bool, len(links))
+	latest := links[:0]
+	for _, link := range links {
+		if !seen[link.Hash] {
+			seen[link.Hash] = true
+			latest = append(latest, link)
+		}
+	}
+	return latest
+}
+
+// missingHashes reports the requested hashes that matched no link.
+func missingHashes(hashes []string, outcomes []BulkOutcome) []BulkOutcome {
+	found := make(map[string]bool, len(outcomes))
+	for _, outcome := range outcomes {
+		found[outcome.Hash] = true
+	}
+
+	missing := []BulkOutcome{}
+	for _, hash := range hashes {
+		if !found[hash] {
+			found[hash] = true
+			missing = append(missing, BulkOutcome{Hash: hash, Status: BULK_STATUS_NOT_FOUND})
+		}
+	}
+	return missing
+}
+
+func (s *LinkService) createLink(store LinkStore, link *Link) (*Link, error) {
+	if link.Hash != "" {
+		return store.Create(link)
+	}
+
+	if s.Codes != nil {
+		return s.createSequential(store, link)
+	}
+
+	collisions := 0
+	for {
+		hash, err := s.Hashes.Generate()
+		if err != nil {
+			return nil, err
+		}
+
+		link.Hash = hash
+		created, err := store.Create(link)
+		s.Hashes.Record(errors.Is(err, ErrHashTaken))
+		if err == nil {
+			return created, nil
+		}
+
+		link.Hash = ""
+		if !errors.Is(err, ErrHashTaken) {
+			return nil, err
+		}
+
+		collisions++
+		if collisions < MAX_HASH_ATTEMPTS {
+			continue
+		}
+
+		if !s.Hashes.Grow() {
+			return nil, fmt.Errorf("no free hash of length %d after %d attempts", s.Hashes.Length(), collisions)
+		}
+		s.Logger.Warn().
+			Int("attempts", collisions).
+			Int("length", s.Hashes.Length()).
+			Msg("Generated hashes keep colliding, growing hash length")
+		collisions = 0
+	}
+}
+
+// createSequential retries when the code of the new ID is held by a custom
+// hash created before sequence mode was enabled. The ID is used up by the
+// failed insert, so the next attempt gets another code.
+func (s *LinkService) createSequential(store LinkStore, link *Link) (*Link, error) {
+	encode := func(id uint) string {
+		return s.Codes.Encode(uint64(id))
+	}
+
+	for attempt := 1; attempt <= MAX_HASH_ATTEMPTS; attempt++ {
+		created, err := store.CreateSequential(link, encode)
+		if !errors.Is(err, ErrHashTaken) {
+			return created, err
+		}
+
+		s.Logger.Warn().
+			Int("attempt", attempt).
+			Msg("Code of the new link is held by a custom hash, retrying")
+	}
+
+	return nil, fmt.Errorf("no free sequence code after %d attempts", MAX_HASH_ATTEMPTS)
+}
+
+// CheckUrl runs the destination URL policy. requestHost is the Host the
+// request came in on, links back to it are refused. Refusals are
+// *urlpolicy.Violation.
+func (s *LinkService) CheckUrl(ctx context.Context, rawUrl, requestHost string) error {
+	if s.UrlPolicy == nil {
+		return nil
+	}
+	return s.UrlPolicy.Check(ctx, rawUrl, requestHost)
+}
+
+// LinkPage reads the page of a listing after the cursor, or before it, the
+// first page when cursor is nil.
+func (s *LinkService) LinkPage(filter LinkFilter, sort LinkSort, cursor *LinkCursor, limit int) (*LinkPage, error) {
+	// One link more tells whether there is another page
+	links, err := s.Repository.GetLinksPage(filter, sort, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLinkPage(links, sort, cursor, limit), nil
+}
+
+// EstimateLinkCount counts the links matching filter and keeps the count
+// for the count cache TTL, so paging through a listing counts it once.
+// Links created or deleted meanwhile show after the TTL.
+func (s *LinkService) EstimateLinkCount(filter LinkFilter) (int64, error) {
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return 0, err
+	}
+	key := string(data)
+
+	if count, ok := s.counts.Get(key); ok {
+		return count, nil
+	}
+
+	count, err := s.Repository.CountUserLinks(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	s.counts.Set(key, count, s.countTtl)
+	return count, nil
+}
+
+// RestoreLink brings the link of userId holding hash back from the trash.
+// ErrNotFound when there is none, ErrHashTaken when a live link holds the
+// hash again.
+func (s *LinkService) RestoreLink(hash, userId string) (*Link, error) {
+	var restored *Link
+	err := s.Repository.Transaction(func(store LinkStore) error {
+		link, err := store.GetDeletedLinkByHash(hash, userId)
+		if err != nil {
+			return err
+		}
+
+		restored, err = store.RestoreLink(link.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Cache.Invalidate(restored.Hash)
+	return restored, nil
+}
+
+// PurgeAt is when the purge job removes a deleted link for good, nil when
+// the trash is kept forever.
+func (s *LinkService) PurgeAt(link *Link) *time.Time {
+	if s.trashRetention <= 0 || !link.DeletedAt.Valid {
+		return nil
 	}
+
+	purgeAt := link.DeletedAt.Time.Add(s.trashRetention).UTC()
+	return &purgeAt
+}
+
+// CheckCustomHash applies the vanity policy to a hash chosen by a user and
+// returns it case-folded. Refusals are *HashPolicyError.
+func (s *LinkService) CheckCustomHash(hash string) (string, error) {
+	hash, err := s.Policy.Normalize(hash)
+	if err != nil {
+		return "", err
+	}
+
+	if s.IsReservedHash(hash) {
+		return "", &HashPolicyError{
+			Rule:    "reserved",
+			Message: "hash has the shape of a generated code, choose another one",
+		}
+	}
+
+	return hash, nil
+}
+
+// IsReservedHash reports whether a custom hash could be produced by the
+// sequence encoding. Such hashes are refused so they never block the code
+// of a future link.
+func (s *LinkService) IsReservedHash(hash string) bool {
+	if s.Codes == nil {
+		return false
+	}
+
+	_, err := s.Codes.Decode(hash)
+	return err == nil
 }
 
 func (s *LinkService) Start() {
 	s.Logger.Info().Msg("Starting link service")
 
+	s.Recorder.Start()
 	go s.runLifetimeManager()
 }
 
+// Stop halts the background jobs and flushes clicks that are still buffered.
 func (s *LinkService) Stop() {
 	s.Logger.Info().Msg("Stopping link service")
 	close(s.stopChan)
+	s.Recorder.Stop()
+}
+
+func (s *LinkService) RecordClick(event *click.ClickEvent) {
+	s.Recorder.Record(event)
+}
+
+// CheckAlive returns an error when the background loop is not running or
+// has not ticked for a while, for instance because a job is stuck.
+func (s *LinkService) CheckAlive() error {
+	last := s.heartbeat.Load()
+	if last == 0 {
+		return errors.New("link service loop is not running")
+	}
+
+	age := time.Since(time.Unix(0, last))
+	if age > HEARTBEAT_TIMEOUT {
+		return fmt.Errorf("link service loop last ticked %s ago", age.Round(time.Second))
+	}
+
+	return nil
 }
 
+// runLifetimeManager deletes expired links and purges the trash right away
+// and then on every tick. Expiry is an absolute timestamp, so restarts do
+// not shift it.
 func (s *LinkService) runLifetimeManager() {
-	ticker := time.NewTicker(24 * time.Hour)
+	ticker := time.NewTicker(s.expiryCheckInterval)
 	defer ticker.Stop()
 
+	heartbeat := time.NewTicker(HEARTBEAT_INTERVAL)
+	defer heartbeat.Stop()
+
+	s.beat()
+	s.processLifetimeUpdate()
+	s.processTrashPurge()
+
 	for {
 		select {
+		case <-heartbeat.C:
+			s.beat()
 		case <-ticker.C:
 			s.processLifetimeUpdate()
+			s.processTrashPurge()
+			s.beat()
 		case <-s.stopChan:
+			s.heartbeat.Store(0)
 			s.Logger.Info().Msg("Lifetime manager stopped")
 			return
 		}
 	}
 }
 
-func (s *LinkService) processLifetimeUpdate() {
-	s.Logger.Info().Msg("Processing lifetime updates for links")
+func (s *LinkService) beat() {
+	s.heartbeat.Store(time.Now().UnixNano())
+}
+
+// RunLifetimeUpdate runs the expiry job on demand, outside of its schedule.
+func (s *LinkService) RunLifetimeUpdate() (int64, error) {
+	return s.processLifetimeUpdate()
+}
+
+func (s *LinkService) processLifetimeUpdate() (int64, error) {
+	s.lifetimeMutex.Lock()
+	defer s.lifetimeMutex.Unlock()
 
-	err := s.Repository.DecrementLifetimeForAllLinks()
+	s.Logger.Info().Msg("Processing expired links")
+
+	start := time.Now()
+	deleted, err := s.Repository.DeleteExpiredLinks(start)
+	metrics.ObserveJob(JOB_LIFETIME, start, err)
 	if err != nil {
-		s.Logger.Error().Err(err).Msg("Failed to decrement lifetime for links")
-		return
+		s.Logger.Error().Err(err).Msg("Failed to delete expired links")
+		return 0, err
 	}
 
-	err = s.Repository.DeleteExpiredLinks()
+	if deleted > 0 {
+		s.Cache.Purge()
+	}
+
+	s.Logger.Info().Int64("deleted_links", deleted).Msg("Lifetime update completed successfully")
+
+	return deleted, nil
+}
+
+// RunTrashPurge runs the trash purge job on demand, outside of its schedule.
+func (s *LinkService) RunTrashPurge() (int64, error) {
+	return s.processTrashPurge()
+}
+
+// processTrashPurge permanently removes the links deleted longer than the
+// trash retention ago. A retention of 0 keeps them.
+func (s *LinkService) processTrashPurge() (int64, error) {
+	if s.trashRetention <= 0 {
+		return 0, nil
+	}
+
+	s.purgeMutex.Lock()
+	defer s.purgeMutex.Unlock()
+
+	start := time.Now()
+	purged, err := s.Repository.PurgeDeletedLinks(start.Add(-s.trashRetention))
+	metrics.ObserveJob(JOB_TRASH_PURGE, start, err)
 	if err != nil {
-		s.Logger.Error().Err(err).Msg("Failed to delete expired links")
-		return
+		s.Logger.Error().Err(err).Msg("Failed to purge deleted links")
+		return 0, err
 	}
 
-	s.Logger.Info().Msg("Lifetime update completed successfully")
+	s.Logger.Info().
+		Int64("purged_links", purged).
+		Dur("retention", s.trashRetention).
+		Msg("Trash purge completed successfully")
+
+	return purged, nil
 }
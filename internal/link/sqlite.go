@@ -0,0 +1,114 @@
+package link
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"UrlShortenerBackend/pkg/db"
+
+	"gorm.io/gorm"
+)
+
+// SQLiteLinkRepository runs the link queries against SQLite. It reuses the
+// Postgres repository for portable SQL and replaces what relies on Postgres
+// date arithmetic.
+type SQLiteLinkRepository struct {
+	*LinkRepository
+}
+
+func NewSQLiteLinkRepository(database *db.Db) *SQLiteLinkRepository {
+	return &SQLiteLinkRepository{
+		LinkRepository: &LinkRepository{
+			Database:     database,
+			likeOperator: "LIKE",
+		},
+	}
+}
+
+func (repo *SQLiteLinkRepository) Transaction(fn func(store LinkStore) error) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&SQLiteLinkRepository{LinkRepository: repo.withTx(tx)})
+	})
+}
+
+// CreateSequential uses up the ID of an attempt whose code is taken. SQLite
+// rolls the AUTOINCREMENT counter back with the failed insert, so without
+// this the retry would get the same ID and code again.
+func (repo *SQLiteLinkRepository) CreateSequential(link *Link, encode func(id uint) string) (*Link, error) {
+	var attempted uint
+	created, err := repo.LinkRepository.CreateSequential(link, func(id uint) string {
+		attempted = id
+		return encode(id)
+	})
+	if errors.Is(err, ErrHashTaken) && attempted != 0 {
+		burn := repo.Database.DB.Exec("UPDATE sqlite_sequence SET seq = MAX(seq, ?) WHERE name = 'links'", attempted)
+		if burn.Error != nil {
+			return nil, fmt.Errorf("error skipping link id %d: %w", attempted, burn.Error)
+		}
+	}
+
+	return created, err
+}
+
+// ExtendExpiry computes the new expiries in Go because SQLite stores
+// timestamps as text and has no interval type.
+func (repo *SQLiteLinkRepository) ExtendExpiry(userId, hash string, by time.Duration) (int64, error) {
+	if hash != "" {
+		matches, err := repo.CheckUserMatchesLink(hash, userId)
+		if err != nil {
+			return 0, err
+		}
+
+		if !matches {
+			return 0, ErrForbidden
+		}
+	} else {
+		exists, err := repo.CheckUserExists(userId)
+		if err != nil {
+			return 0, fmt.Errorf("error checking user existence: %w", err)
+		}
+
+		if !exists {
+			return 0, ErrUserNotFound
+		}
+	}
+
+	var updated int64
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("user_id = ? AND expires_at IS NOT NULL", userId)
+		if hash != "" {
+			query = query.Where("hash = ?", hash)
+		}
+
+		var links []Link
+		if err := query.Find(&links).Error; err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, link := range links {
+			expiresAt := extendedExpiry(*link.ExpiresAt, now, by)
+			if err := tx.Model(&Link{}).Where("id = ?", link.ID).Update("expires_at", expiresAt).Error; err != nil {
+				return err
+			}
+		}
+
+		updated = int64(len(links))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error extending links expiry: %w", err)
+	}
+
+	return updated, nil
+}
+
+// extendedExpiry mirrors GREATEST(expires_at, NOW()) + by.
+func extendedExpiry(expiresAt, now time.Time, by time.Duration) time.Time {
+	if expiresAt.Before(now) {
+		expiresAt = now
+	}
+
+	return expiresAt.Add(by)
+}
@@ -0,0 +1,54 @@
+package link
+
+import (
+	"time"
+
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/pkg/db"
+)
+
+// LinkStore is the storage used by the link handlers and services.
+// LinkRepository backs it with Postgres, SQLiteLinkRepository with SQLite
+// and MemoryLinkStore keeps links in process. Whatever the backend, failures
+// are reported with the sentinels in errors.go (ErrNotFound, ErrHashTaken...).
+type LinkStore interface {
+	GetAllLinks(filter LinkFilter, sort LinkSort, page, limit int) (*PaginationResult, error)
+	GetLinksPage(filter LinkFilter, sort LinkSort, cursor *LinkCursor, limit int) ([]Link, error)
+	CountUserLinks(filter LinkFilter) (int64, error)
+	SearchAllLinks(filter AdminLinkFilter, page, limit int) (*PaginationResult, error)
+	GetLinkByHash(hash string, userId string) (*Link, error)
+	GetLinkById(id uint) (*Link, error)
+	Create(link *Link) (*Link, error)
+	CreateSequential(link *Link, encode func(id uint) string) (*Link, error)
+	Update(link *Link) (*Link, error)
+	DeleteExpiredLinks(now time.Time) (int64, error)
+	RecordClicks(events []*click.ClickEvent) error
+	DeleteLink(hash string, userId string) error
+	ForceDeleteLink(id uint) error
+	RestoreLink(id uint) (*Link, error)
+	GetDeletedLinks(userId string, page, limit int) (*PaginationResult, error)
+	GetDeletedLinkByHash(hash, userId string) (*Link, error)
+	PurgeDeletedLinks(deletedBefore time.Time) (int64, error)
+	CheckUserExists(userId string) (bool, error)
+	CheckUserMatchesLink(hash, userId string) (bool, error)
+	ExtendExpiry(userId, hash string, by time.Duration) (int64, error)
+	FindLinks(filter LinkFilter, limit int) ([]Link, error)
+	StreamLinks(filter LinkFilter, batchSize int, fn func(links []Link) error) error
+	DeleteLinks(ids []uint) (int64, error)
+	SetExpiry(id uint, expiresAt time.Time) error
+	CountLinks(now, expiringBefore time.Time) (*LinkCounts, error)
+	Transaction(fn func(store LinkStore) error) error
+}
+
+// NewLinkStore returns the store for the configured database driver. The
+// database is nil for the memory driver, whose clicks are saved to clicks.
+func NewLinkStore(driver string, database *db.Db, clicks click.ClickStore) LinkStore {
+	switch driver {
+	case db.DRIVER_MEMORY:
+		return NewMemoryLinkStore(clicks)
+	case db.DRIVER_SQLITE:
+		return NewSQLiteLinkRepository(database)
+	}
+
+	return NewLinkRepository(database)
+}
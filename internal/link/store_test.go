@@ -0,0 +1,630 @@
+package link
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	configs "UrlShortenerBackend/config"
+	"UrlShortenerBackend/internal/click"
+	"UrlShortenerBackend/migrations"
+	"UrlShortenerBackend/pkg/db"
+	"UrlShortenerBackend/pkg/migrate"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testStores are the LinkStore backends that run without a database
+// server. Every contract test runs against each of them.
+var testStores = []struct {
+	name string
+	open func(t testing.TB) LinkStore
+}{
+	{"memory", openMemoryStore},
+	{"sqlite", openSQLiteStore},
+}
+
+func openMemoryStore(t testing.TB) LinkStore {
+	return NewLinkStore(db.DRIVER_MEMORY, nil, click.NewClickStore(db.DRIVER_MEMORY, nil))
+}
+
+// openSQLiteStore migrates a fresh database file in the test directory.
+func openSQLiteStore(t testing.TB) LinkStore {
+	t.Helper()
+
+	database := db.NewDb(&configs.Config{Db: configs.DbConfig{
+		Driver: db.DRIVER_SQLITE,
+		Dsn:    filepath.Join(t.TempDir(), "links.db"),
+	}})
+	// Keep the expected misses out of the test output
+	database.DB = database.Session(&gorm.Session{Logger: logger.Discard})
+	t.Cleanup(func() {
+		sqlDB, _ := database.DB.DB()
+		sqlDB.Close()
+	})
+
+	migrator, err := migrate.NewMigrator(database.DB, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewLinkStore(db.DRIVER_SQLITE, database, click.NewClickStore(db.DRIVER_SQLITE, database))
+}
+
+func forEachStore(t *testing.T, fn func(t *testing.T, store LinkStore)) {
+	for _, backend := range testStores {
+		t.Run(backend.name, func(t *testing.T) {
+			fn(t, backend.open(t))
+		})
+	}
+}
+
+func mustCreate(t *testing.T, store LinkStore, link *Link) *Link {
+	t.Helper()
+
+	created, err := store.Create(link)
+	if err != nil {
+		t.Fatalf("Create(%q) error = %v", link.Hash, err)
+	}
+	return created
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func linkHashes(links []Link) []string {
+	hashes := make([]string, len(links))
+	for i, link := range links {
+		hashes[i] = link.Hash
+	}
+	return hashes
+}
+
+func TestStoreCreateAndGet(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		expiresAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+		created := mustCreate(t, store, &Link{
+			Url:       "https://Example.org/page",
+			Hash:      "abc",
+			UserId:    "alice",
+			Title:     "Page",
+			Tags:      Tags{"one", "two"},
+			ExpiresAt: &expiresAt,
+		})
+		if created.ID == 0 {
+			t.Fatal("Create left the ID unset")
+		}
+
+		anonymous := mustCreate(t, store, &Link{Url: "https://example.org/", Hash: "anon"})
+		if anonymous.UserId == "" {
+			t.Error("Create left the user of an anonymous link unset")
+		}
+
+		got, err := store.GetLinkByHash("abc", "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != created.ID || got.Url != created.Url || got.Title != "Page" {
+			t.Errorf("GetLinkByHash() = %+v, want %+v", got, created)
+		}
+		if !slices.Equal(got.Tags, Tags{"one", "two"}) {
+			t.Errorf("tags = %v", got.Tags)
+		}
+		if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) {
+			t.Errorf("expires_at = %v, want %v", got.ExpiresAt, expiresAt)
+		}
+
+		if _, err := store.GetLinkByHash("abc", ""); err != nil {
+			t.Errorf("GetLinkByHash() of any user error = %v", err)
+		}
+		if got, err := store.GetLinkById(created.ID); err != nil || got.Hash != "abc" {
+			t.Errorf("GetLinkById() = %v, %v", got, err)
+		}
+
+		notFound := []struct {
+			name string
+			get  func() (*Link, error)
+		}{
+			{"other user", func() (*Link, error) { return store.GetLinkByHash("abc", "bob") }},
+			{"unknown hash", func() (*Link, error) { return store.GetLinkByHash("nope", "") }},
+			{"unknown id", func() (*Link, error) { return store.GetLinkById(9999) }},
+		}
+		for _, tt := range notFound {
+			if _, err := tt.get(); !errors.Is(err, ErrNotFound) {
+				t.Errorf("%s: error = %v, want ErrNotFound", tt.name, err)
+			}
+		}
+	})
+}
+
+func TestStoreHashUniqueness(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		link := mustCreate(t, store, &Link{Url: "https://example.org/", Hash: "taken", UserId: "alice"})
+
+		_, err := store.Create(&Link{Url: "https://example.org/", Hash: "taken", UserId: "bob"})
+		if !errors.Is(err, ErrHashTaken) || errors.Is(err, ErrHashInTrash) {
+			t.Errorf("Create() of a live hash error = %v, want ErrHashTaken", err)
+		}
+
+		if err := store.DeleteLink("taken", "alice"); err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.Create(&Link{Url: "https://example.org/", Hash: "taken", UserId: "bob"})
+		if !errors.Is(err, ErrHashInTrash) || !errors.Is(err, ErrHashTaken) {
+			t.Errorf("Create() of a trashed hash error = %v, want ErrHashInTrash", err)
+		}
+
+		if err := store.ForceDeleteLink(link.ID); err != nil {
+			t.Fatal(err)
+		}
+		mustCreate(t, store, &Link{Url: "https://example.org/", Hash: "taken", UserId: "bob"})
+	})
+}
+
+func TestStoreCreateSequential(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		encode := func(id uint) string {
+			return "seq" + string(rune('a'+id))
+		}
+
+		first, err := store.CreateSequential(&Link{Url: "https://example.org/1", UserId: "alice"}, encode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.Hash != encode(first.ID) {
+			t.Errorf("hash = %q, want %q", first.Hash, encode(first.ID))
+		}
+
+		// The next code is held by a custom hash, the ID is used up anyway
+		mustCreate(t, store, &Link{Url: "https://example.org/", Hash: encode(first.ID + 2), UserId: "alice"})
+		_, err = store.CreateSequential(&Link{Url: "https://example.org/2", UserId: "alice"}, encode)
+		if !errors.Is(err, ErrHashTaken) {
+			t.Fatalf("CreateSequential() onto a custom hash error = %v, want ErrHashTaken", err)
+		}
+
+		next, err := store.CreateSequential(&Link{Url: "https://example.org/2", UserId: "alice"}, encode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.ID <= first.ID+2 || next.Hash != encode(next.ID) {
+			t.Errorf("retry got id %d and hash %q", next.ID, next.Hash)
+		}
+	})
+}
+
+func TestStoreUpdate(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		link := mustCreate(t, store, &Link{Url: "https://example.org/", Hash: "one", UserId: "alice"})
+		mustCreate(t, store, &Link{Url: "https://example.org/", Hash: "two", UserId: "alice"})
+
+		expiresAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+		link.Url = "https://sub.example.net/new"
+		link.Hash = "renamed"
+		link.Title = "New"
+		link.Tags = Tags{"x"}
+		link.ExpiresAt = &expiresAt
+		link.setPasswordHash("bcrypt-hash")
+		if _, err := store.Update(link); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := store.GetLinkByHash("renamed", "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Url != link.Url || got.Title != "New" || !slices.Equal(got.Tags, Tags{"x"}) ||
+			got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) || !got.PasswordProtected {
+			t.Errorf("updated link = %+v", got)
+		}
+		if _, err := store.GetLinkByHash("one", ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("old hash error = %v, want ErrNotFound", err)
+		}
+
+		// Clearing the expiry
+		got.ExpiresAt = nil
+		if _, err := store.Update(got); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := store.GetLinkById(link.ID); got.ExpiresAt != nil {
+			t.Errorf("expires_at = %v after clearing it", got.ExpiresAt)
+		}
+
+		got.Hash = "two"
+		if _, err := store.Update(got); !errors.Is(err, ErrHashTaken) {
+			t.Errorf("Update() onto a live hash error = %v, want ErrHashTaken", err)
+		}
+
+		if _, err := store.Update(&Link{ID: 9999, Hash: "ghost", Url: "https://example.org/"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update() of an unknown link error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestStoreFilters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		now := time.Now().UTC()
+		soon := now.Add(time.Hour)
+		later := now.Add(48 * time.Hour)
+
+		links := []*Link{
+			{Hash: "alpha", Url: "https://example.org/a", Title: "Spring Sale", Tags: Tags{"promo"}, ExpiresAt: &soon},
+			{Hash: "alps", Url: "https://shop.example.org/b", Tags: Tags{"promo", "shop"}, ExpiresAt: &later},
+			{Hash: "Alpine", Url: "https://other.net/100%_off"},
+			{Hash: "a_c", Url: "https://notexample.org/"},
+			{Hash: "gone", Url: "https://example.org/gone"},
+		}
+		for _, link := range links {
+			link.UserId = "alice"
+			mustCreate(t, store, link)
+		}
+		mustCreate(t, store, &Link{Hash: "bobs", Url: "https://example.org/", UserId: "bob"})
+
+		if err := store.RecordClicks([]*click.ClickEvent{
+			{LinkId: links[0].ID, Hash: "alpha", CreatedAt: now},
+			{LinkId: links[0].ID, Hash: "alpha", CreatedAt: now},
+			{LinkId: links[1].ID, Hash: "alps", CreatedAt: now},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteLink("gone", "alice"); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name   string
+			filter LinkFilter
+			want   []string
+		}{
+			{"all live", LinkFilter{}, []string{"Alpine", "a_c", "alpha", "alps"}},
+			{"hashes", LinkFilter{Hashes: []string{"alps", "bobs", "gone"}}, []string{"alps"}},
+			{"hash prefix is case-sensitive", LinkFilter{HashPrefix: "Al"}, []string{"Alpine"}},
+			{"hash prefix escapes wildcards", LinkFilter{HashPrefix: "a_"}, []string{"a_c"}},
+			{"tag", LinkFilter{Tag: "shop"}, []string{"alps"}},
+			{"tag is matched whole", LinkFilter{Tag: "prom"}, []string{}},
+			{"domain and subdomains", LinkFilter{Domain: "Example.org."}, []string{"alpha", "alps"}},
+			{"search title any case", LinkFilter{Search: "spring sale"}, []string{"alpha"}},
+			{"search escapes wildcards", LinkFilter{Search: "100%_"}, []string{"Alpine"}},
+			{"min clicks", LinkFilter{MinClicks: 1}, []string{"alpha", "alps"}},
+			{"expires before", LinkFilter{ExpiresTo: timePtr(now.Add(2 * time.Hour))}, []string{"alpha"}},
+			{"expires after", LinkFilter{ExpiresFrom: timePtr(now.Add(2 * time.Hour))}, []string{"alps"}},
+			{"created range", LinkFilter{CreatedFrom: timePtr(now.Add(-time.Hour)), CreatedTo: timePtr(now.Add(time.Hour))}, []string{"Alpine", "a_c", "alpha", "alps"}},
+			{"created in the future", LinkFilter{CreatedFrom: timePtr(now.Add(time.Hour))}, []string{}},
+			{"deleted only", LinkFilter{Deleted: DELETED_ONLY}, []string{"gone"}},
+			{"deleted included", LinkFilter{Deleted: DELETED_INCLUDE, Domain: "example.org"}, []string{"alpha", "alps", "gone"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.filter.UserId = "alice"
+
+				found, err := store.FindLinks(tt.filter, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := linkHashes(found)
+				slices.Sort(got)
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("FindLinks() = %v, want %v", got, tt.want)
+				}
+
+				count, err := store.CountUserLinks(tt.filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if count != int64(len(tt.want)) {
+					t.Errorf("CountUserLinks() = %d, want %d", count, len(tt.want))
+				}
+			})
+		}
+
+		if found, _ := store.FindLinks(LinkFilter{}, 0); len(found) != 0 {
+			t.Errorf("FindLinks() without a user = %v, want none", linkHashes(found))
+		}
+		if found, _ := store.FindLinks(LinkFilter{UserId: "alice"}, 2); len(found) != 2 {
+			t.Errorf("FindLinks() with limit 2 returned %d links", len(found))
+		}
+	})
+}
+
+func TestStoreGetAllLinks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		for _, hash := range []string{"l1", "l2", "l3", "l4", "l5"} {
+			mustCreate(t, store, &Link{Hash: hash, Url: "https://example.org/", UserId: "alice"})
+		}
+		mustCreate(t, store, &Link{Hash: "bobs", Url: "https://example.org/", UserId: "bob"})
+
+		result, err := store.GetAllLinks(LinkFilter{UserId: "alice"}, LinkSort{Field: LINK_SORT_CREATED_AT, Desc: true}, 2, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.TotalLinks != 5 || result.TotalPages != 3 || result.Page != 2 || result.Limit != 2 {
+			t.Errorf("pagination = %d links, %d pages, page %d, limit %d",
+				result.TotalLinks, result.TotalPages, result.Page, result.Limit)
+		}
+		if got := linkHashes(result.Links); !slices.Equal(got, []string{"l3", "l2"}) {
+			t.Errorf("page 2 = %v, want [l3 l2]", got)
+		}
+
+		search, err := store.SearchAllLinks(AdminLinkFilter{Query: "L4"}, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := linkHashes(search.Links); !slices.Equal(got, []string{"l4"}) {
+			t.Errorf("SearchAllLinks() = %v, want [l4]", got)
+		}
+	})
+}
+
+func TestStoreTrash(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		link := mustCreate(t, store, &Link{Hash: "trash", Url: "https://example.org/", UserId: "alice"})
+		mustCreate(t, store, &Link{Hash: "keep", Url: "https://example.org/", UserId: "alice"})
+
+		if err := store.DeleteLink("trash", "bob"); !errors.Is(err, ErrForbidden) {
+			t.Errorf("DeleteLink() by another user error = %v, want ErrForbidden", err)
+		}
+		if err := store.DeleteLink("trash", "alice"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetLinkByHash("trash", ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("deleted link error = %v, want ErrNotFound", err)
+		}
+		if matches, _ := store.CheckUserMatchesLink("trash", "alice"); matches {
+			t.Error("CheckUserMatchesLink() matches a deleted link")
+		}
+
+		deleted, err := store.GetDeletedLinks("alice", 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := linkHashes(deleted.Links); !slices.Equal(got, []string{"trash"}) {
+			t.Errorf("GetDeletedLinks() = %v, want [trash]", got)
+		}
+		if _, err := store.GetDeletedLinkByHash("trash", "bob"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetDeletedLinkByHash() by another user error = %v, want ErrNotFound", err)
+		}
+
+		if _, err := store.RestoreLink(link.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetLinkByHash("trash", "alice"); err != nil {
+			t.Errorf("restored link error = %v", err)
+		}
+		if _, err := store.RestoreLink(link.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RestoreLink() of a live link error = %v, want ErrNotFound", err)
+		}
+
+		// Purging keeps links deleted after the cutoff
+		if err := store.DeleteLink("trash", "alice"); err != nil {
+			t.Fatal(err)
+		}
+		if purged, err := store.PurgeDeletedLinks(time.Now().UTC().Add(-time.Hour)); err != nil || purged != 0 {
+			t.Errorf("PurgeDeletedLinks() before the deletion = %d, %v", purged, err)
+		}
+		if purged, err := store.PurgeDeletedLinks(time.Now().UTC().Add(time.Second)); err != nil || purged != 1 {
+			t.Errorf("PurgeDeletedLinks() = %d, %v, want 1", purged, err)
+		}
+		if _, err := store.GetDeletedLinkByHash("trash", "alice"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("purged link error = %v, want ErrNotFound", err)
+		}
+
+		ids := []uint{}
+		for _, hash := range []string{"b1", "b2"} {
+			ids = append(ids, mustCreate(t, store, &Link{Hash: hash, Url: "https://example.org/", UserId: "alice"}).ID)
+		}
+		if deleted, err := store.DeleteLinks(append(ids, 9999)); err != nil || deleted != 2 {
+			t.Errorf("DeleteLinks() = %d, %v, want 2", deleted, err)
+		}
+		if deleted, err := store.DeleteLinks(ids); err != nil || deleted != 0 {
+			t.Errorf("DeleteLinks() again = %d, %v, want 0", deleted, err)
+		}
+	})
+}
+
+func TestStoreExpiry(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		now := time.Now().UTC()
+		past := now.Add(-time.Minute)
+		soon := now.Add(time.Hour)
+		later := now.Add(30 * 24 * time.Hour)
+
+		mustCreate(t, store, &Link{Hash: "past", Url: "https://example.org/", UserId: "alice", ExpiresAt: &past})
+		mustCreate(t, store, &Link{Hash: "soon", Url: "https://example.org/", UserId: "alice", ExpiresAt: &soon})
+		mustCreate(t, store, &Link{Hash: "later", Url: "https://example.org/", UserId: "alice", ExpiresAt: &later})
+		forever := mustCreate(t, store, &Link{Hash: "forever", Url: "https://example.org/", UserId: "bob"})
+
+		counts, err := store.CountLinks(now, now.Add(24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counts.Active != 3 || counts.ExpiringSoon != 1 {
+			t.Errorf("CountLinks() = %+v, want 3 active and 1 expiring soon", counts)
+		}
+
+		if deleted, err := store.DeleteExpiredLinks(now); err != nil || deleted != 1 {
+			t.Errorf("DeleteExpiredLinks() = %d, %v, want 1", deleted, err)
+		}
+		if _, err := store.GetLinkByHash("past", ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expired link error = %v, want ErrNotFound", err)
+		}
+
+		if updated, err := store.ExtendExpiry("alice", "soon", 24*time.Hour); err != nil || updated != 1 {
+			t.Errorf("ExtendExpiry() of one link = %d, %v, want 1", updated, err)
+		}
+		if got, _ := store.GetLinkByHash("soon", ""); !got.ExpiresAt.After(now.Add(24 * time.Hour)) {
+			t.Errorf("extended expiry = %v", got.ExpiresAt)
+		}
+		if updated, err := store.ExtendExpiry("alice", "", time.Hour); err != nil || updated != 2 {
+			t.Errorf("ExtendExpiry() of every link = %d, %v, want 2", updated, err)
+		}
+		if updated, err := store.ExtendExpiry("bob", "", time.Hour); err != nil || updated != 0 {
+			t.Errorf("ExtendExpiry() of links without expiry = %d, %v, want 0", updated, err)
+		}
+
+		errorTests := []struct {
+			name   string
+			userId string
+			hash   string
+			want   error
+		}{
+			{"other user", "bob", "soon", ErrForbidden},
+			{"unknown user", "carol", "", ErrUserNotFound},
+		}
+		for _, tt := range errorTests {
+			if _, err := store.ExtendExpiry(tt.userId, tt.hash, time.Hour); !errors.Is(err, tt.want) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+			}
+		}
+
+		if err := store.SetExpiry(forever.ID, later); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := store.GetLinkById(forever.ID); got.ExpiresAt == nil || !got.ExpiresAt.Equal(later) {
+			t.Errorf("SetExpiry() stored %v, want %v", got.ExpiresAt, later)
+		}
+		if err := store.SetExpiry(9999, later); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetExpiry() of an unknown link error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestStoreUsers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		mustCreate(t, store, &Link{Hash: "mine", Url: "https://example.org/", UserId: "alice"})
+
+		tests := []struct {
+			userId string
+			exists bool
+		}{
+			{"alice", true},
+			{"bob", false},
+			{"", false},
+		}
+		for _, tt := range tests {
+			if exists, err := store.CheckUserExists(tt.userId); err != nil || exists != tt.exists {
+				t.Errorf("CheckUserExists(%q) = %v, %v, want %v", tt.userId, exists, err, tt.exists)
+			}
+		}
+
+		if matches, err := store.CheckUserMatchesLink("mine", "alice"); err != nil || !matches {
+			t.Errorf("CheckUserMatchesLink() of the owner = %v, %v", matches, err)
+		}
+		if matches, err := store.CheckUserMatchesLink("mine", "bob"); err != nil || matches {
+			t.Errorf("CheckUserMatchesLink() of another user = %v, %v", matches, err)
+		}
+		if _, err := store.CheckUserMatchesLink("", "alice"); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("CheckUserMatchesLink() without hash error = %v, want ErrInvalidInput", err)
+		}
+	})
+}
+
+func TestStoreStreamLinks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		for _, hash := range []string{"s1", "s2", "s3", "s4", "s5"} {
+			mustCreate(t, store, &Link{Hash: hash, Url: "https://example.org/", UserId: "alice"})
+		}
+
+		batches := [][]string{}
+		err := store.StreamLinks(LinkFilter{UserId: "alice"}, 2, func(links []Link) error {
+			batches = append(batches, linkHashes(links))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(batches) != 3 || !slices.Equal(slices.Concat(batches...), []string{"s1", "s2", "s3", "s4", "s5"}) {
+			t.Errorf("StreamLinks() batches = %v", batches)
+		}
+
+		stop := errors.New("stop")
+		calls := 0
+		err = store.StreamLinks(LinkFilter{UserId: "alice"}, 2, func(links []Link) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("StreamLinks() returned %v after %d calls, want stop after 1", err, calls)
+		}
+	})
+}
+
+func TestStoreRecordClicks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		first := mustCreate(t, store, &Link{Hash: "c1", Url: "https://example.org/", UserId: "alice"})
+		second := mustCreate(t, store, &Link{Hash: "c2", Url: "https://example.org/", UserId: "alice"})
+
+		now := time.Now().UTC()
+		events := []*click.ClickEvent{
+			{LinkId: first.ID, Hash: "c1", CreatedAt: now},
+			{LinkId: first.ID, Hash: "c1", CreatedAt: now},
+			{LinkId: second.ID, Hash: "c2", CreatedAt: now},
+		}
+		if err := store.RecordClicks(events); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			hash string
+			want int64
+		}{
+			{"c1", 2},
+			{"c2", 1},
+		}
+		for _, tt := range tests {
+			got, err := store.GetLinkByHash(tt.hash, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.NumberOfClicks != tt.want {
+				t.Errorf("link %s has %d clicks, want %d", tt.hash, got.NumberOfClicks, tt.want)
+			}
+		}
+	})
+}
+
+func TestStoreTransaction(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store LinkStore) {
+		failure := errors.New("failure")
+
+		err := store.Transaction(func(tx LinkStore) error {
+			mustCreate(t, tx, &Link{Hash: "rolled", Url: "https://example.org/", UserId: "alice"})
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("Transaction() error = %v, want failure", err)
+		}
+		if _, err := store.GetLinkByHash("rolled", ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("rolled back link error = %v, want ErrNotFound", err)
+		}
+
+		err = store.Transaction(func(tx LinkStore) error {
+			mustCreate(t, tx, &Link{Hash: "outer", Url: "https://example.org/", UserId: "alice"})
+
+			// A failed nested transaction only undoes its own writes
+			nested := tx.Transaction(func(tx LinkStore) error {
+				mustCreate(t, tx, &Link{Hash: "inner", Url: "https://example.org/", UserId: "alice"})
+				return failure
+			})
+			if !errors.Is(nested, failure) {
+				t.Errorf("nested Transaction() error = %v, want failure", nested)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetLinkByHash("outer", ""); err != nil {
+			t.Errorf("committed link error = %v", err)
+		}
+		if _, err := store.GetLinkByHash("inner", ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("nested rolled back link error = %v, want ErrNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,336 @@
+package link
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportColumns is the CSV header of an export. Import reads the columns of
+// LinkCreateRequest by name and ignores the others, so an export can be
+// imported again as is.
+var exportColumns = []string{"hash", "url", "title", "tags", "expires_at", "created_at", "number_of_clicks"}
+
+// transferFormat picks the format of an export or import from the format
+// query parameter, or else from the Content-Type of the body.
+func transferFormat(format, contentType string) (string, error) {
+	switch format {
+	case TRANSFER_FORMAT_CSV, TRANSFER_FORMAT_JSON, TRANSFER_FORMAT_NDJSON:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown format %q, expected csv, json or ndjson", format)
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return TRANSFER_FORMAT_CSV, nil
+	case strings.HasPrefix(contentType, "application/x-ndjson"):
+		return TRANSFER_FORMAT_NDJSON, nil
+	case strings.HasPrefix(contentType, "application/json"):
+		return TRANSFER_FORMAT_JSON, nil
+	}
+
+	return TRANSFER_FORMAT_CSV, nil
+}
+
+func transferContentType(format string) string {
+	switch format {
+	case TRANSFER_FORMAT_JSON:
+		return "application/json"
+	case TRANSFER_FORMAT_NDJSON:
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func newExportRow(link *Link) *LinkExportRow {
+	return &LinkExportRow{
+		Hash:           link.Hash,
+		Url:            link.Url,
+		Title:          link.Title,
+		Tags:           append([]string{}, link.Tags...),
+		ExpiresAt:      link.ExpiresAt,
+		CreatedAt:      link.CreatedAt,
+		NumberOfClicks: link.NumberOfClicks,
+	}
+}
+
+// linkEncoder writes exported links one at a time. Flush pushes what is
+// buffered to the underlying writer, Close ends the document.
+type linkEncoder interface {
+	Encode(row *LinkExportRow) error
+	Flush() error
+	Close() error
+}
+
+func newLinkEncoder(format string, w io.Writer) (linkEncoder, error) {
+	switch format {
+	case TRANSFER_FORMAT_JSON:
+		return &jsonEncoder{writer: w}, nil
+	case TRANSFER_FORMAT_NDJSON:
+		return &ndjsonEncoder{encoder: json.NewEncoder(w)}, nil
+	}
+
+	encoder := &csvEncoder{writer: csv.NewWriter(w)}
+	return encoder, encoder.writer.Write(exportColumns)
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvEncoder) Encode(row *LinkExportRow) error {
+	expiresAt := ""
+	if row.ExpiresAt != nil {
+		expiresAt = row.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	return e.writer.Write([]string{
+		row.Hash,
+		row.Url,
+		row.Title,
+		strings.Join(row.Tags, ","),
+		expiresAt,
+		row.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatInt(row.NumberOfClicks, 10),
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	return e.Flush()
+}
+
+// jsonEncoder writes one JSON array, element by element.
+type jsonEncoder struct {
+	writer io.Writer
+	count  int
+}
+
+func (e *jsonEncoder) Encode(row *LinkExportRow) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	separator := ","
+	if e.count == 0 {
+		separator = "["
+	}
+	e.count++
+
+	_, err = io.WriteString(e.writer, separator+string(data))
+	return err
+}
+
+func (e *jsonEncoder) Flush() error {
+	return nil
+}
+
+func (e *jsonEncoder) Close() error {
+	closing := "]\n"
+	if e.count == 0 {
+		closing = "[]\n"
+	}
+
+	_, err := io.WriteString(e.writer, closing)
+	return err
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(row *LinkExportRow) error {
+	return e.encoder.Encode(row)
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// rowError is a row of an import that cannot be read. The rows after it
+// can, unlike after any other error of a linkDecoder.
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rowError) Unwrap() error {
+	return e.err
+}
+
+// linkDecoder reads the rows of an import one at a time and returns io.EOF
+// after the last one.
+type linkDecoder interface {
+	Next() (*LinkCreateRequest, error)
+}
+
+func newLinkDecoder(format string, r io.Reader) (linkDecoder, error) {
+	switch format {
+	case TRANSFER_FORMAT_JSON:
+		return newJsonDecoder(r)
+	case TRANSFER_FORMAT_NDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		return &ndjsonDecoder{scanner: scanner}, nil
+	}
+
+	return newCsvDecoder(r)
+}
+
+// csvDecoder maps the columns of the header onto LinkCreateRequest. The url
+// column is required, tags are separated by commas inside their cell.
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCsvDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the CSV has no header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.New("the CSV header has no url column")
+	}
+
+	return &csvDecoder{reader: reader, columns: columns}, nil
+}
+
+func (d *csvDecoder) Next() (*LinkCreateRequest, error) {
+	record, err := d.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &rowError{err: parseErr}
+		}
+		return nil, err
+	}
+
+	cell := func(column string) string {
+		i, ok := d.columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	payload := &LinkCreateRequest{
+		Url:   cell("url"),
+		Hash:  cell("hash"),
+		Title: cell("title"),
+	}
+
+	if tags := cell("tags"); tags != "" {
+		payload.Tags = strings.Split(tags, ",")
+	}
+
+	if expiresAt := cell("expires_at"); expiresAt != "" {
+		parsed, err := parseTime(expiresAt)
+		if err != nil {
+			return nil, &rowError{err: fmt.Errorf("expires_at: %w", err)}
+		}
+		payload.ExpiresAt = &parsed
+	}
+
+	return payload, nil
+}
+
+// jsonDecoder reads the elements of a JSON array. An element with values
+// of the wrong type is a row error, broken JSON ends the import.
+type jsonDecoder struct {
+	decoder *json.Decoder
+}
+
+func newJsonDecoder(r io.Reader) (*jsonDecoder, error) {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error reading JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("the JSON body must be an array of links")
+	}
+
+	return &jsonDecoder{decoder: decoder}, nil
+}
+
+func (d *jsonDecoder) Next() (*LinkCreateRequest, error) {
+	if !d.decoder.More() {
+		if _, err := d.decoder.Token(); err != nil {
+			return nil, fmt.Errorf("error reading JSON: %w", err)
+		}
+		return nil, io.EOF
+	}
+
+	var payload LinkCreateRequest
+	if err := d.decoder.Decode(&payload); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("error reading JSON: %w", err)
+		}
+		return nil, &rowError{err: err}
+	}
+
+	return &payload, nil
+}
+
+// ndjsonDecoder reads one JSON object per line and skips blank lines.
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+}
+
+func (d *ndjsonDecoder) Next() (*LinkCreateRequest, error) {
+	for d.scanner.Scan() {
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var payload LinkCreateRequest
+		if err := json.Unmarshal([]byte(line), &payload); err != nil {
+			return nil, &rowError{err: err}
+		}
+		return &payload, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,201 @@
+package urlpolicy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// blocklistEntries is one loaded version of the blocklist.
+type blocklistEntries struct {
+	domains  map[string]bool
+	networks []netip.Prefix
+}
+
+// Blocklist refuses destinations on blocked domains, their subdomains
+// included, and hosts inside blocked networks. Entries come from the config
+// and from an optional file that Reload reads again, so the list can change
+// without a restart. When resolveHosts is set, host names are resolved and
+// every address is checked against the networks.
+type Blocklist struct {
+	domains      []string
+	networks     []string
+	file         string
+	resolveHosts bool
+	resolver     *net.Resolver
+	entries      atomic.Pointer[blocklistEntries]
+}
+
+// BlocklistStats describes the loaded blocklist.
+type BlocklistStats struct {
+	Domains  int    `json:"domains" example:"120"`
+	Networks int    `json:"networks" example:"9"`
+	File     string `json:"file,omitempty" example:"config/blocklist.txt"`
+}
+
+func NewBlocklist(domains, networks []string, file string, resolveHosts bool) (*Blocklist, error) {
+	blocklist := &Blocklist{
+		domains:      domains,
+		networks:     networks,
+		file:         file,
+		resolveHosts: resolveHosts,
+		resolver:     net.DefaultResolver,
+	}
+
+	if _, err := blocklist.Reload(); err != nil {
+		return nil, err
+	}
+
+	return blocklist, nil
+}
+
+// Reload rebuilds the blocklist from the config and the file. On error the
+// previous entries stay in place.
+func (b *Blocklist) Reload() (BlocklistStats, error) {
+	entries := &blocklistEntries{domains: map[string]bool{}}
+
+	lines := append(append([]string{}, b.domains...), b.networks...)
+	if b.file != "" {
+		fileLines, err := readLines(b.file)
+		if err != nil {
+			return b.Stats(), err
+		}
+		lines = append(lines, fileLines...)
+	}
+
+	for _, line := range lines {
+		if err := entries.add(line); err != nil {
+			return b.Stats(), err
+		}
+	}
+
+	b.entries.Store(entries)
+
+	return b.Stats(), nil
+}
+
+func (b *Blocklist) Stats() BlocklistStats {
+	stats := BlocklistStats{File: b.file}
+	if entries := b.entries.Load(); entries != nil {
+		stats.Domains = len(entries.domains)
+		stats.Networks = len(entries.networks)
+	}
+	return stats
+}
+
+func (b *Blocklist) Check(ctx context.Context, target *Target) error {
+	entries := b.entries.Load()
+
+	if domain, ok := entries.blockedDomain(target.Host); ok {
+		return &Violation{
+			Rule:    RULE_BLOCKED_DOMAIN,
+			Message: fmt.Sprintf("url points to the blocked domain %s", domain),
+		}
+	}
+
+	if addr, err := netip.ParseAddr(target.Host); err == nil {
+		return entries.checkAddr(addr)
+	}
+
+	if !b.resolveHosts {
+		return nil
+	}
+
+	addrs, err := b.resolver.LookupNetIP(ctx, "ip", target.Host)
+	if err != nil {
+		// Unresolvable hosts cannot reach a blocked network right now
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := entries.checkAddr(addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BlocksAddr reports whether addr is inside a blocked network, used to
+// guard outgoing connections.
+func (b *Blocklist) BlocksAddr(addr netip.Addr) bool {
+	return b.entries.Load().checkAddr(addr) != nil
+}
+
+func (entries *blocklistEntries) add(line string) error {
+	line = strings.ToLower(strings.TrimSpace(line))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	if strings.Contains(line, "/") {
+		prefix, err := netip.ParsePrefix(line)
+		if err != nil {
+			return fmt.Errorf("invalid blocked network %q: %w", line, err)
+		}
+		entries.networks = append(entries.networks, prefix.Masked())
+		return nil
+	}
+
+	if addr, err := netip.ParseAddr(line); err == nil {
+		entries.networks = append(entries.networks, netip.PrefixFrom(addr, addr.BitLen()))
+		return nil
+	}
+
+	entries.domains[strings.TrimSuffix(strings.TrimPrefix(line, "*."), ".")] = true
+	return nil
+}
+
+// blockedDomain matches host and each of its parent domains.
+func (entries *blocklistEntries) blockedDomain(host string) (string, bool) {
+	for domain := host; domain != ""; {
+		if entries.domains[domain] {
+			return domain, true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return "", false
+}
+
+func (entries *blocklistEntries) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, network := range entries.networks {
+		if network.Contains(addr) {
+			return &Violation{
+				Rule:    RULE_BLOCKED_NETWORK,
+				Message: fmt.Sprintf("url points to the blocked network %s", network),
+			}
+		}
+	}
+	return nil
+}
+
+// readLines returns the lines of a blocklist file. Text after # is a
+// comment.
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening blocklist: %w", err)
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading blocklist %s: %w", path, err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,29 @@
+package urlpolicy
+
+import (
+	configs "UrlShortenerBackend/config"
+)
+
+// New builds the policy described by the config: allowed schemes, self
+// references, the blocklist and, when enabled, redirect following. Extra
+// self hosts, such as the listen address, are added to the configured ones.
+func New(cfg configs.UrlPolicyConfig, selfHosts ...string) (*Policy, error) {
+	blocklist, err := NewBlocklist(cfg.BlockedDomains, cfg.BlockedNetworks, cfg.BlocklistFile, cfg.ResolveHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := NewPolicy(
+		append(append([]string{}, cfg.SelfHosts...), selfHosts...),
+		Schemes(cfg.AllowedSchemes),
+		SelfReference(),
+		blocklist,
+	)
+	policy.Blocklist = blocklist
+
+	if cfg.FollowRedirects {
+		policy.FollowRedirects(cfg.MaxRedirects, cfg.RedirectTimeout, blocklist)
+	}
+
+	return policy, nil
+}
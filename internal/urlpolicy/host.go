@@ -0,0 +1,105 @@
+package urlpolicy
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var errInvalidIPv4 = errors.New("invalid IPv4 address")
+
+// targetHost returns the normalized host of u. Hosts that browsers read as
+// an IPv4 address, such as "2130706433", "0x7f.1" or "017700000001", are
+// rewritten to dotted-quad form so the address rules see 127.0.0.1.
+func targetHost(u *url.URL) (string, error) {
+	host := normalizeHost(u.Hostname())
+	if strings.Contains(host, ":") || !endsInNumber(host) {
+		return host, nil
+	}
+
+	addr, err := parseIPv4(host)
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+// endsInNumber reports whether the last label of host is numeric, which
+// makes the whole host an IPv4 address in the WHATWG URL standard.
+func endsInNumber(host string) bool {
+	labels := strings.Split(host, ".")
+	last := labels[len(labels)-1]
+	if last == "" {
+		return false
+	}
+
+	if rest, ok := cutHexPrefix(last); ok {
+		return strings.Trim(rest, "0123456789abcdefABCDEF") == ""
+	}
+	return strings.Trim(last, "0123456789") == ""
+}
+
+// parseIPv4 follows inet_aton: up to four parts in decimal, octal with a
+// leading 0 or hexadecimal with 0x, the last part filling the remaining
+// bytes.
+func parseIPv4(host string) (netip.Addr, error) {
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, errInvalidIPv4
+	}
+
+	var value uint64
+	for i, part := range parts {
+		number, err := parseIPv4Number(part)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		if i < len(parts)-1 {
+			if number > 255 {
+				return netip.Addr{}, errInvalidIPv4
+			}
+			value = value<<8 | number
+			continue
+		}
+
+		remaining := uint(5 - len(parts))
+		if number >= 1<<(8*remaining) {
+			return netip.Addr{}, errInvalidIPv4
+		}
+		value = value<<(8*remaining) | number
+	}
+
+	return netip.AddrFrom4([4]byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}), nil
+}
+
+func parseIPv4Number(part string) (uint64, error) {
+	if part == "" {
+		return 0, errInvalidIPv4
+	}
+
+	base := 10
+	if rest, ok := cutHexPrefix(part); ok {
+		if rest == "" {
+			return 0, nil
+		}
+		part, base = rest, 16
+	} else if len(part) > 1 && part[0] == '0' {
+		part, base = part[1:], 8
+	}
+
+	number, err := strconv.ParseUint(part, base, 64)
+	if err != nil {
+		return 0, errInvalidIPv4
+	}
+	return number, nil
+}
+
+func cutHexPrefix(part string) (string, bool) {
+	if len(part) >= 2 && part[0] == '0' && (part[1] == 'x' || part[1] == 'X') {
+		return part[2:], true
+	}
+	return part, false
+}
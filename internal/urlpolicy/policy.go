@@ -0,0 +1,186 @@
+// Package urlpolicy decides whether a destination URL may be shortened. A
+// Policy runs a list of rules in order and stops at the first violation.
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Rules reported in Violation.Rule
+const (
+	RULE_INVALID         = "invalid_url"
+	RULE_SCHEME          = "scheme"
+	RULE_SELF_REFERENCE  = "self_reference"
+	RULE_REDIRECT_LOOP   = "redirect_loop"
+	RULE_REDIRECT_CHAIN  = "redirect_chain"
+	RULE_BLOCKED_DOMAIN  = "blocked_domain"
+	RULE_BLOCKED_NETWORK = "blocked_network"
+)
+
+// Violation explains why a destination URL was refused.
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Target is the destination under check. SelfHosts are the host names the
+// shortener answers on, including the one of the current request.
+type Target struct {
+	URL       *url.URL
+	Host      string
+	SelfHosts []string
+}
+
+// Rule checks one aspect of a destination. It returns a *Violation to
+// refuse it, any other error is a failure of the check itself.
+type Rule interface {
+	Check(ctx context.Context, target *Target) error
+}
+
+type RuleFunc func(ctx context.Context, target *Target) error
+
+func (f RuleFunc) Check(ctx context.Context, target *Target) error {
+	return f(ctx, target)
+}
+
+type Policy struct {
+	// Blocklist is the reloadable blocklist among the rules, nil when the
+	// policy was built without one
+	Blocklist *Blocklist
+	rules     []Rule
+	selfHosts []string
+	follower  *redirectFollower
+}
+
+// NewPolicy returns a policy running rules in order. selfHosts are added to
+// the hosts passed to Check.
+func NewPolicy(selfHosts []string, rules ...Rule) *Policy {
+	return &Policy{
+		rules:     rules,
+		selfHosts: normalizeHosts(selfHosts),
+	}
+}
+
+// Use appends rules to the policy. It is meant for setup, before Check is
+// called concurrently.
+func (p *Policy) Use(rules ...Rule) {
+	p.rules = append(p.rules, rules...)
+}
+
+// Check parses raw and runs every rule. requestHosts are extra hosts that
+// point to the shortener, usually the Host header of the request.
+func (p *Policy) Check(ctx context.Context, raw string, requestHosts ...string) error {
+	target, err := p.newTarget(raw, requestHosts)
+	if err != nil {
+		return err
+	}
+
+	if err := p.checkTarget(ctx, target); err != nil {
+		return err
+	}
+
+	if p.follower != nil {
+		return p.followRedirects(ctx, target)
+	}
+
+	return nil
+}
+
+func (p *Policy) newTarget(raw string, requestHosts []string) (*Target, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, &Violation{Rule: RULE_INVALID, Message: "url could not be parsed"}
+	}
+
+	host, err := targetHost(parsed)
+	if err != nil {
+		return nil, &Violation{Rule: RULE_INVALID, Message: "url host is not a valid IPv4 address"}
+	}
+
+	selfHosts := append(normalizeHosts(requestHosts), p.selfHosts...)
+
+	return &Target{
+		URL:       parsed,
+		Host:      host,
+		SelfHosts: selfHosts,
+	}, nil
+}
+
+func (p *Policy) checkTarget(ctx context.Context, target *Target) error {
+	for _, rule := range p.rules {
+		if err := rule.Check(ctx, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schemes allows only the listed schemes, compared case-insensitively, and
+// requires a host so that "http:foo" is refused too.
+func Schemes(allowed []string) Rule {
+	set := make(map[string]bool, len(allowed))
+	for _, scheme := range allowed {
+		set[strings.ToLower(strings.TrimSpace(scheme))] = true
+	}
+
+	return RuleFunc(func(ctx context.Context, target *Target) error {
+		scheme := strings.ToLower(target.URL.Scheme)
+		if !set[scheme] {
+			return &Violation{
+				Rule:    RULE_SCHEME,
+				Message: fmt.Sprintf("url scheme %q is not allowed, use one of %s", scheme, strings.Join(allowed, ", ")),
+			}
+		}
+		if target.Host == "" {
+			return &Violation{Rule: RULE_INVALID, Message: "url must have a host"}
+		}
+		return nil
+	})
+}
+
+// SelfReference refuses destinations on the shortener itself, which would
+// redirect to another short link or loop.
+func SelfReference() Rule {
+	return RuleFunc(func(ctx context.Context, target *Target) error {
+		for _, host := range target.SelfHosts {
+			if target.Host == host {
+				return &Violation{
+					Rule:    RULE_SELF_REFERENCE,
+					Message: "url points back to the shortener",
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func normalizeHosts(hosts []string) []string {
+	normalized := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host = normalizeHost(host); host != "" {
+			normalized = append(normalized, host)
+		}
+	}
+	return normalized
+}
+
+// normalizeHost lowercases a host and drops the port and trailing dot.
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if _, err := netip.ParseAddr(host); err != nil {
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
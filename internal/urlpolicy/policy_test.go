@@ -0,0 +1,236 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestPolicy(t *testing.T) *Policy {
+	t.Helper()
+
+	blocklist, err := NewBlocklist(
+		[]string{"localhost", "*.evil.example"},
+		[]string{"10.0.0.0/8", "127.0.0.0/8", "169.254.0.0/16", "::1/128", "fc00::/7"},
+		"",
+		false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewPolicy([]string{"sho.rt"}, Schemes([]string{"http", "https"}), SelfReference(), blocklist)
+}
+
+// checkRule runs the policy and returns the rule of the violation, "" when
+// the URL is allowed.
+func checkRule(t *testing.T, policy *Policy, raw string, requestHosts ...string) string {
+	t.Helper()
+
+	err := policy.Check(context.Background(), raw, requestHosts...)
+	if err == nil {
+		return ""
+	}
+
+	var violation *Violation
+	if !errors.As(err, &violation) {
+		t.Fatalf("Check(%q) error = %v, want a *Violation", raw, err)
+	}
+	return violation.Rule
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := newTestPolicy(t)
+
+	tests := []struct {
+		name string
+		url  string
+		rule string
+	}{
+		{"https", "https://example.org/page", ""},
+		{"http with port", "http://example.org:8080/", ""},
+		{"scheme any case", "HTTPS://example.org", ""},
+		{"ftp", "ftp://example.org/file", RULE_SCHEME},
+		{"javascript", "javascript:alert(1)", RULE_SCHEME},
+		{"no host", "http:foo", RULE_INVALID},
+		{"unparsable", "http://[::1", RULE_INVALID},
+		{"self host", "https://sho.rt/abc", RULE_SELF_REFERENCE},
+		{"self host any case with dot", "https://SHO.RT./abc", RULE_SELF_REFERENCE},
+		{"request host", "https://alias.example/abc", RULE_SELF_REFERENCE},
+		{"blocked domain", "http://localhost/admin", RULE_BLOCKED_DOMAIN},
+		{"blocked subdomain", "http://a.b.evil.example/", RULE_BLOCKED_DOMAIN},
+		{"blocked domain itself", "http://evil.example/", RULE_BLOCKED_DOMAIN},
+		{"similar domain", "http://notevil.example/", ""},
+		{"private network", "http://10.1.2.3/", RULE_BLOCKED_NETWORK},
+		{"loopback", "http://127.0.0.1:8080/", RULE_BLOCKED_NETWORK},
+		{"metadata", "http://169.254.169.254/latest/meta-data", RULE_BLOCKED_NETWORK},
+		{"ipv6 loopback", "http://[::1]/", RULE_BLOCKED_NETWORK},
+		{"ipv4 mapped ipv6", "http://[::ffff:127.0.0.1]/", RULE_BLOCKED_NETWORK},
+		{"unique local", "http://[fd00::1]/", RULE_BLOCKED_NETWORK},
+		{"public address", "http://93.184.216.34/", ""},
+		{"decimal address", "http://2130706433/", RULE_BLOCKED_NETWORK},
+		{"hex address", "http://0x7f000001/", RULE_BLOCKED_NETWORK},
+		{"octal address", "http://017700000001/", RULE_BLOCKED_NETWORK},
+		{"short address", "http://127.1/", RULE_BLOCKED_NETWORK},
+		{"mixed address", "http://0x7f.0.0.1/", RULE_BLOCKED_NETWORK},
+		{"address with dot", "http://127.0.0.1./", RULE_BLOCKED_NETWORK},
+		{"out of range address", "http://256.0.0.1/", RULE_INVALID},
+		{"too many parts", "http://1.2.3.4.5/", RULE_INVALID},
+		{"numeric label not last", "http://1.example/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkRule(t, policy, tt.url, "alias.example"); got != tt.rule {
+				t.Errorf("Check(%q) rule = %q, want %q", tt.url, got, tt.rule)
+			}
+		})
+	}
+}
+
+func TestTargetHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"Example.ORG", "example.org"},
+		{"2130706433", "127.0.0.1"},
+		{"0x7f.1", "127.0.0.1"},
+		{"017700000001", "127.0.0.1"},
+		{"127.1", "127.0.0.1"},
+		{"10.0x10.1", "10.16.0.1"},
+		{"0x", "0.0.0.0"},
+		{"[::1]", "::1"},
+		{"a.0x1g", "a.0x1g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got, err := targetHost(&url.URL{Host: tt.host})
+			if err != nil {
+				t.Fatalf("targetHost(%q) error = %v", tt.host, err)
+			}
+			if got != tt.want {
+				t.Errorf("targetHost(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlocklistReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(file, []byte("spam.example # comment\n192.0.2.0/24\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	blocklist, err := NewBlocklist(nil, nil, file, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := NewPolicy(nil, blocklist)
+
+	if got := checkRule(t, policy, "http://spam.example/"); got != RULE_BLOCKED_DOMAIN {
+		t.Errorf("rule = %q, want %q", got, RULE_BLOCKED_DOMAIN)
+	}
+	if got := checkRule(t, policy, "http://192.0.2.10/"); got != RULE_BLOCKED_NETWORK {
+		t.Errorf("rule = %q, want %q", got, RULE_BLOCKED_NETWORK)
+	}
+
+	if err := os.WriteFile(file, []byte("other.example\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := blocklist.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Domains != 1 || stats.Networks != 0 {
+		t.Errorf("stats = %+v, want 1 domain and no network", stats)
+	}
+	if got := checkRule(t, policy, "http://spam.example/"); got != "" {
+		t.Errorf("rule after reload = %q, want none", got)
+	}
+
+	// A broken file keeps the previous entries
+	if err := os.WriteFile(file, []byte("300.0.0.0/8\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blocklist.Reload(); err == nil {
+		t.Error("expected an error for an invalid network")
+	}
+	if got := checkRule(t, policy, "http://other.example/"); got != RULE_BLOCKED_DOMAIN {
+		t.Errorf("rule after failed reload = %q, want %q", got, RULE_BLOCKED_DOMAIN)
+	}
+}
+
+func TestFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/chain":
+			http.Redirect(w, r, "/one", http.StatusFound)
+		case "/one":
+			http.Redirect(w, r, "/two", http.StatusFound)
+		case "/two":
+			http.Redirect(w, r, "/end", http.StatusFound)
+		case "/self":
+			http.Redirect(w, r, "https://sho.rt/abc", http.StatusMovedPermanently)
+		case "/internal":
+			http.Redirect(w, r, "http://10.0.0.1/", http.StatusFound)
+		case "/numeric":
+			http.Redirect(w, r, "http://0xa.1/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	blocklist := mustBlocklist(t, "10.0.0.0/8", "0.0.0.0/8")
+	policy := NewPolicy([]string{"sho.rt"}, Schemes([]string{"http", "https"}), SelfReference(), blocklist)
+	policy.FollowRedirects(2, time.Second, blocklist)
+
+	tests := []struct {
+		path string
+		rule string
+	}{
+		{"/end", ""},
+		{"/one", ""},
+		{"/loop", RULE_REDIRECT_LOOP},
+		{"/chain", RULE_REDIRECT_CHAIN},
+		{"/self", RULE_REDIRECT_LOOP},
+		{"/internal", RULE_BLOCKED_NETWORK},
+		{"/numeric", RULE_BLOCKED_NETWORK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := checkRule(t, policy, server.URL+tt.path); got != tt.rule {
+				t.Errorf("Check(%s) rule = %q, want %q", tt.path, got, tt.rule)
+			}
+		})
+	}
+
+	// The guard refuses to dial blocked networks, so the chain is not
+	// followed past the check of the first hop
+	guarded := NewPolicy(nil, Schemes([]string{"http"}))
+	guarded.FollowRedirects(2, time.Second, mustBlocklist(t, "127.0.0.0/8", "::1/128"))
+	if got := checkRule(t, guarded, server.URL+"/loop"); got != "" {
+		t.Errorf("guarded Check rule = %q, want none", got)
+	}
+}
+
+func mustBlocklist(t *testing.T, networks ...string) *Blocklist {
+	t.Helper()
+
+	blocklist, err := NewBlocklist(nil, networks, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blocklist
+}
@@ -0,0 +1,133 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var errBlockedDial = errors.New("connection to a blocked network refused")
+
+// redirectFollower walks the redirect chain of a destination with HEAD
+// requests. Every hop goes through the rules of the policy again, so a
+// chain through another shortener back to this one is caught as a loop.
+type redirectFollower struct {
+	client  *http.Client
+	maxHops int
+}
+
+// FollowRedirects makes Check request the destination and follow up to
+// maxHops redirects. Connections to networks blocked by guard are refused
+// at dial time, so the check cannot be used to probe internal hosts.
+// Network failures end the walk without refusing the URL.
+func (p *Policy) FollowRedirects(maxHops int, timeout time.Duration, guard *Blocklist) {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if guard != nil && guard.BlocksAddr(addrPort.Addr()) {
+				return errBlockedDial
+			}
+			return nil
+		},
+	}
+
+	p.follower = &redirectFollower{
+		maxHops: maxHops,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (p *Policy) followRedirects(ctx context.Context, target *Target) error {
+	visited := map[string]bool{target.URL.String(): true}
+
+	current := target
+	for hop := 0; ; hop++ {
+		location, ok := p.follower.next(ctx, current)
+		if !ok {
+			return nil
+		}
+
+		if hop >= p.follower.maxHops {
+			return &Violation{
+				Rule:    RULE_REDIRECT_CHAIN,
+				Message: fmt.Sprintf("url redirects more than %d times", p.follower.maxHops),
+			}
+		}
+
+		next := current.URL.ResolveReference(location)
+		if visited[next.String()] {
+			return &Violation{Rule: RULE_REDIRECT_LOOP, Message: "url redirects in a loop"}
+		}
+		visited[next.String()] = true
+
+		host, err := targetHost(next)
+		if err != nil {
+			return &Violation{
+				Rule:    RULE_INVALID,
+				Message: fmt.Sprintf("url redirects to %s, whose host is not a valid IPv4 address", next.Redacted()),
+			}
+		}
+		current = &Target{
+			URL:       next,
+			Host:      host,
+			SelfHosts: target.SelfHosts,
+		}
+
+		err = p.checkTarget(ctx, current)
+		var violation *Violation
+		if errors.As(err, &violation) {
+			if violation.Rule == RULE_SELF_REFERENCE {
+				return &Violation{Rule: RULE_REDIRECT_LOOP, Message: "url redirects back to the shortener"}
+			}
+			return &Violation{
+				Rule:    violation.Rule,
+				Message: fmt.Sprintf("url redirects to %s: %s", next.Redacted(), violation.Message),
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// next returns the Location of a redirect response, or false when the
+// destination does not redirect or cannot be reached.
+func (f *redirectFollower) next(ctx context.Context, target *Target) (location *url.URL, ok bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target.URL.String(), nil)
+	if err != nil {
+		return nil, false
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return nil, false
+	}
+
+	parsed, err := resp.Location()
+	if err != nil {
+		return nil, false
+	}
+
+	return parsed, true
+}
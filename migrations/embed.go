@@ -0,0 +1,9 @@
+// Package migrations holds the numbered SQL migrations of every SQL driver.
+// Each driver has its own directory with NNNN_name.up.sql and
+// NNNN_name.down.sql files, versions are kept in step across drivers.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS links (
+    id               BIGSERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ,
+    deleted_at       TIMESTAMPTZ,
+    url              TEXT,
+    hash             TEXT,
+    user_id          TEXT,
+    number_of_clicks BIGINT DEFAULT 0,
+    lifetime         BIGINT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_links_hash ON links (hash) WHERE deleted_at IS NULL;
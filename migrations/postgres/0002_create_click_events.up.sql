@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS click_events (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    link_id           BIGINT NOT NULL,
+    hash              TEXT,
+    referrer          TEXT,
+    user_agent        TEXT,
+    user_agent_family TEXT,
+    accept_language   TEXT,
+    ip_hash           TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_click_events_link_created ON click_events (link_id, created_at);
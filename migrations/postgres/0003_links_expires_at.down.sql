@@ -0,0 +1,7 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS lifetime BIGINT;
+
+UPDATE links
+SET lifetime = GREATEST(CEIL(EXTRACT(EPOCH FROM expires_at - NOW()) / 86400), 0)
+WHERE expires_at IS NOT NULL;
+
+ALTER TABLE links DROP COLUMN IF EXISTS expires_at;
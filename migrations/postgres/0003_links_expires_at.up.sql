@@ -0,0 +1,16 @@
+-- Links used to carry a lifetime in days that a background job decremented
+-- once a day. It is converted into an absolute expiry relative to now.
+ALTER TABLE links ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_links_expires_at ON links (expires_at);
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'links' AND column_name = 'lifetime'
+    ) THEN
+        UPDATE links SET expires_at = NOW() + lifetime * INTERVAL '1 day' WHERE expires_at IS NULL;
+        ALTER TABLE links DROP COLUMN lifetime;
+    END IF;
+END $$;
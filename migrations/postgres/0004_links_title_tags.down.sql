@@ -0,0 +1,3 @@
+ALTER TABLE links DROP COLUMN IF EXISTS tags;
+
+ALTER TABLE links DROP COLUMN IF EXISTS title;
@@ -0,0 +1,4 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS title TEXT;
+
+-- Tags are stored as ",a,b," so a single tag can be matched with LIKE
+ALTER TABLE links ADD COLUMN IF NOT EXISTS tags TEXT NOT NULL DEFAULT '';
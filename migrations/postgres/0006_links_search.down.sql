@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_links_user_clicks;
+DROP INDEX IF EXISTS idx_links_user_expires_at;
+DROP INDEX IF EXISTS idx_links_user_created_at;
+DROP INDEX IF EXISTS idx_links_user_hash;
+DROP INDEX IF EXISTS idx_links_host_trgm;
+DROP INDEX IF EXISTS idx_links_tags_trgm;
+DROP INDEX IF EXISTS idx_links_title_trgm;
+DROP INDEX IF EXISTS idx_links_url_trgm;
+
+ALTER TABLE links DROP COLUMN IF EXISTS host;
@@ -0,0 +1,22 @@
+-- Host of the destination URL, kept in step with url by the application,
+-- so that links can be filtered by domain in SQL
+ALTER TABLE links ADD COLUMN IF NOT EXISTS host TEXT NOT NULL DEFAULT '';
+
+UPDATE links
+SET host = rtrim(lower(btrim(substring(url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?(\[[^]]*\]|[^/?#:]*)'), '[]')), '.')
+WHERE url ~ '^[A-Za-z][A-Za-z0-9+.-]*://';
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Free-text search over url and title, tags and subdomains are matched with
+-- a leading wildcard, which only trigram indexes can serve
+CREATE INDEX IF NOT EXISTS idx_links_url_trgm ON links USING gin (url gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_links_title_trgm ON links USING gin (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_links_tags_trgm ON links USING gin (tags gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_links_host_trgm ON links USING gin (host gin_trgm_ops);
+
+-- Listing of one user: hash prefixes and each sort key, id breaking ties
+CREATE INDEX IF NOT EXISTS idx_links_user_hash ON links (user_id, hash text_pattern_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_links_user_created_at ON links (user_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_links_user_expires_at ON links (user_id, expires_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_links_user_clicks ON links (user_id, number_of_clicks, id) WHERE deleted_at IS NULL;
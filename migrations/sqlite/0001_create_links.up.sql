@@ -0,0 +1,19 @@
+-- SQLite support arrived after links switched to an absolute expiry, so the
+-- table is created in its current shape and 0003/0004 have nothing to do.
+CREATE TABLE IF NOT EXISTS links (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at       DATETIME,
+    updated_at       DATETIME,
+    deleted_at       DATETIME,
+    url              TEXT,
+    hash             TEXT,
+    user_id          TEXT,
+    number_of_clicks INTEGER DEFAULT 0,
+    expires_at       DATETIME,
+    title            TEXT,
+    tags             TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_links_hash ON links (hash) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_links_expires_at ON links (expires_at);
@@ -0,0 +1 @@
+-- Already part of 0001_create_links on SQLite
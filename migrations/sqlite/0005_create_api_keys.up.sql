@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   DATETIME,
+    updated_at   DATETIME,
+    user_id      TEXT NOT NULL,
+    name         TEXT,
+    prefix       TEXT,
+    key_hash     TEXT NOT NULL,
+    last_used_at DATETIME,
+    revoked_at   DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_links_user_clicks;
+DROP INDEX IF EXISTS idx_links_user_expires_at;
+DROP INDEX IF EXISTS idx_links_user_created_at;
+DROP INDEX IF EXISTS idx_links_user_host;
+
+ALTER TABLE links DROP COLUMN host;
@@ -0,0 +1,21 @@
+-- Host of the destination URL, kept in step with url by the application,
+-- so that links can be filtered by domain in SQL
+ALTER TABLE links ADD COLUMN host TEXT NOT NULL DEFAULT '';
+
+-- SQLite has no regular expressions, the host is cut out step by step:
+-- scheme, then path, query and fragment, then user info and port
+UPDATE links SET host = lower(substr(url, instr(url, '://') + 3)) WHERE instr(url, '://') > 0;
+UPDATE links SET host = substr(host, 1, instr(host, '/') - 1) WHERE instr(host, '/') > 0;
+UPDATE links SET host = substr(host, 1, instr(host, '?') - 1) WHERE instr(host, '?') > 0;
+UPDATE links SET host = substr(host, 1, instr(host, '#') - 1) WHERE instr(host, '#') > 0;
+UPDATE links SET host = substr(host, instr(host, '@') + 1) WHERE instr(host, '@') > 0;
+UPDATE links SET host = substr(host, 1, instr(host, ':') - 1) WHERE instr(host, ':') > 0 AND host NOT LIKE '[%';
+UPDATE links SET host = substr(host, 2, instr(host, ']') - 2) WHERE host LIKE '[%]%';
+UPDATE links SET host = rtrim(host, '.');
+
+-- Listing of one user: domains and each sort key, id breaking ties.
+-- Search and tags use LIKE scans, SQLite has no trigram indexes
+CREATE INDEX IF NOT EXISTS idx_links_user_host ON links (user_id, host) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_links_user_created_at ON links (user_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_links_user_expires_at ON links (user_id, expires_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_links_user_clicks ON links (user_id, number_of_clicks, id) WHERE deleted_at IS NULL;
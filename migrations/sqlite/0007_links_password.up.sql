@@ -0,0 +1,2 @@
+-- Bcrypt hash of the password of protected links, empty for public ones
+ALTER TABLE links ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
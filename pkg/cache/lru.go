@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LRU is a size-bounded cache evicting the least recently used entry, where
+// every entry also expires after its own TTL. It is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	mutex    sync.Mutex
+	capacity int
+	items    map[K]*list.Element
+	order    *list.List
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+type Stats struct {
+	Size      int   `json:"size" example:"1250"`
+	Capacity  int   `json:"capacity" example:"10000"`
+	Hits      int64 `json:"hits" example:"98231"`
+	Misses    int64 `json:"misses" example:"1742"`
+	Evictions int64 `json:"evictions" example:"12"`
+}
+
+func NewLRU[K comparable, V any](capacity int) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		var zero V
+		return zero, false
+	}
+
+	item := element.Value.(*entry[K, V])
+	if !time.Now().Before(item.expiresAt) {
+		c.removeElement(element)
+		c.misses.Add(1)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits.Add(1)
+	return item.value, true
+}
+
+func (c *LRU[K, V]) Set(key K, value V, ttl time.Duration) {
+	if c.capacity <= 0 || ttl <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+
+	if element, ok := c.items[key]; ok {
+		item := element.Value.(*entry[K, V])
+		item.value = value
+		item.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *LRU[K, V]) Delete(keys ...K) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.items[key]; ok {
+			c.removeElement(element)
+		}
+	}
+}
+
+func (c *LRU[K, V]) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+func (c *LRU[K, V]) Stats() Stats {
+	c.mutex.Lock()
+	size := c.order.Len()
+	c.mutex.Unlock()
+
+	return Stats{
+		Size:      size,
+		Capacity:  c.capacity,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+func (c *LRU[K, V]) removeElement(element *list.Element) {
+	item := element.Value.(*entry[K, V])
+	delete(c.items, item.key)
+	c.order.Remove(element)
+}
@@ -1,21 +1,69 @@
 package db
 
 import (
+	"fmt"
+
 	configs "UrlShortenerBackend/config"
 
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+const (
+	DRIVER_POSTGRES = "postgres"
+	DRIVER_SQLITE   = "sqlite"
+	DRIVER_MEMORY   = "memory"
+)
+
 type Db struct {
 	*gorm.DB
 }
 
 func NewDb(cfg *configs.Config) *Db {
-	db, err := gorm.Open(postgres.Open(cfg.Db.Dsn), &gorm.Config{})
+	dialector, err := Dialector(cfg.Db)
 	if err != nil {
 		panic(err)
 	}
 
+	// TranslateError turns driver specific unique violations into
+	// gorm.ErrDuplicatedKey, so stores can report ErrHashTaken.
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		panic(err)
+	}
+
+	// SQLite has a single writer. With one connection concurrent writes
+	// wait their turn in the pool instead of failing with "database is
+	// locked".
+	if cfg.Db.Driver == DRIVER_SQLITE {
+		sqlDB, err := db.DB()
+		if err != nil {
+			panic(err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
 	return &Db{db}
 }
+
+// Dialector picks the GORM driver for the configured database. The memory
+// driver has no SQL database behind it.
+func Dialector(cfg configs.DbConfig) (gorm.Dialector, error) {
+	if cfg.Dsn == "" {
+		return nil, fmt.Errorf("db.dsn is required for the %s driver", cfg.Driver)
+	}
+
+	switch cfg.Driver {
+	case DRIVER_POSTGRES, "":
+		return postgres.Open(cfg.Dsn), nil
+	case DRIVER_SQLITE:
+		return sqlite.Open(cfg.Dsn), nil
+	}
+
+	return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+}
+
+func (db *Db) IsSQLite() bool {
+	return db.Dialector.Name() == DRIVER_SQLITE
+}
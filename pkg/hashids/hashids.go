@@ -0,0 +1,157 @@
+// Package hashids turns sequence numbers into short, non-sequential codes
+// and back. The encoding follows Hashids: the alphabet is shuffled with a
+// salt, every code starts with a "lottery" character and each digit is
+// written with an alphabet reshuffled by the character before it, so
+// neighbouring numbers produce unrelated codes. Decoding re-encodes the
+// result, so only codes this Codec could have produced are accepted.
+package hashids
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+var ErrInvalidCode = errors.New("invalid code")
+
+type Codec struct {
+	alphabet []byte
+	salt     []byte
+	// offset is added before encoding so every code is at least minLength
+	// characters long
+	offset uint64
+}
+
+// New returns a Codec for alphabet and salt. Codes are at least minLength
+// characters long, a minLength of 2 or less gives the shortest codes.
+func New(alphabet, salt string, minLength int) (*Codec, error) {
+	if len(alphabet) < 16 {
+		return nil, fmt.Errorf("alphabet must have at least 16 characters, got %d", len(alphabet))
+	}
+
+	seen := make(map[byte]bool, len(alphabet))
+	for i := 0; i < len(alphabet); i++ {
+		if seen[alphabet[i]] {
+			return nil, fmt.Errorf("alphabet contains %q twice", alphabet[i])
+		}
+		seen[alphabet[i]] = true
+	}
+
+	// The first character is the lottery, the rest are digits
+	var offset uint64
+	if minLength > 2 {
+		offset = 1
+		for range minLength - 2 {
+			hi, lo := bits.Mul64(offset, uint64(len(alphabet)))
+			if hi != 0 {
+				return nil, fmt.Errorf("min length %d is too large for an alphabet of %d characters", minLength, len(alphabet))
+			}
+			offset = lo
+		}
+	}
+
+	shuffled := []byte(alphabet)
+	shuffle(shuffled, []byte(salt))
+
+	return &Codec{
+		alphabet: shuffled,
+		salt:     []byte(salt),
+		offset:   offset,
+	}, nil
+}
+
+func (c *Codec) Encode(n uint64) string {
+	n += c.offset
+
+	size := uint64(len(c.alphabet))
+	digits := []uint64{}
+	for {
+		digits = append(digits, n%size)
+		n /= size
+		if n == 0 {
+			break
+		}
+	}
+
+	lottery := c.alphabet[digits[0]]
+	code := make([]byte, 0, len(digits)+1)
+	code = append(code, lottery)
+
+	alphabet := c.alphabet
+	previous := lottery
+	for i := len(digits) - 1; i >= 0; i-- {
+		alphabet = c.reshuffle(alphabet, previous)
+		previous = alphabet[digits[i]]
+		code = append(code, previous)
+	}
+
+	return string(code)
+}
+
+// Decode returns the number code was encoded from, or ErrInvalidCode.
+func (c *Codec) Decode(code string) (uint64, error) {
+	if len(code) < 2 {
+		return 0, ErrInvalidCode
+	}
+
+	size := uint64(len(c.alphabet))
+	alphabet := c.alphabet
+	var n uint64
+	for i := 1; i < len(code); i++ {
+		alphabet = c.reshuffle(alphabet, code[i-1])
+		position := bytes.IndexByte(alphabet, code[i])
+		if position < 0 {
+			return 0, ErrInvalidCode
+		}
+
+		hi, lo := bits.Mul64(n, size)
+		if hi != 0 || lo > math.MaxUint64-uint64(position) {
+			return 0, ErrInvalidCode
+		}
+		n = lo + uint64(position)
+	}
+
+	if n < c.offset {
+		return 0, ErrInvalidCode
+	}
+
+	if c.Encode(n-c.offset) != code {
+		return 0, ErrInvalidCode
+	}
+
+	return n - c.offset, nil
+}
+
+// reshuffle returns a copy of alphabet shuffled with the character written
+// before the next digit, so every digit is read with its own alphabet.
+func (c *Codec) reshuffle(alphabet []byte, previous byte) []byte {
+	shuffled := make([]byte, len(alphabet))
+	copy(shuffled, alphabet)
+
+	key := make([]byte, 0, len(alphabet))
+	key = append(key, previous)
+	key = append(key, c.salt...)
+	key = append(key, alphabet...)
+	shuffle(shuffled, key[:len(alphabet)])
+
+	return shuffled
+}
+
+// shuffle is the consistent shuffle of Hashids, a Fisher-Yates shuffle
+// driven by salt instead of random numbers.
+func shuffle(alphabet, salt []byte) {
+	if len(salt) == 0 {
+		return
+	}
+
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		integer := int(salt[v])
+		p += integer
+		j := (integer + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+		v++
+	}
+}
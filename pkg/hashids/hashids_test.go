@@ -0,0 +1,126 @@
+package hashids
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+const testAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		minLength int
+	}{
+		{"shortest", 0},
+		{"min length 2", 2},
+		{"min length 6", 6},
+		{"min length 10", 10},
+	}
+
+	numbers := []uint64{0, 1, 2, 61, 62, 63, 1000, 123456789, math.MaxUint32, math.MaxUint64 / 2}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := New(testAlphabet, "salt", tt.minLength)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := make(map[string]uint64)
+			for _, n := range numbers {
+				code := codec.Encode(n)
+				if len(code) < tt.minLength {
+					t.Errorf("Encode(%d) = %q, shorter than %d", n, code, tt.minLength)
+				}
+				if other, ok := seen[code]; ok {
+					t.Errorf("Encode(%d) = Encode(%d) = %q", n, other, code)
+				}
+				seen[code] = n
+
+				got, err := codec.Decode(code)
+				if err != nil {
+					t.Fatalf("Decode(%q) error = %v", code, err)
+				}
+				if got != n {
+					t.Errorf("Decode(Encode(%d)) = %d", n, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMinLengthIsExact(t *testing.T) {
+	for _, minLength := range []int{3, 5, 8} {
+		codec, err := New(testAlphabet, "salt", minLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The smallest numbers get exactly the minimum length
+		if code := codec.Encode(0); len(code) != minLength {
+			t.Errorf("min length %d: Encode(0) = %q", minLength, code)
+		}
+	}
+}
+
+func TestSaltChangesCodes(t *testing.T) {
+	a, _ := New(testAlphabet, "one", 6)
+	b, _ := New(testAlphabet, "two", 6)
+
+	code := a.Encode(42)
+	if code == b.Encode(42) {
+		t.Errorf("salts one and two both encode 42 as %q", code)
+	}
+	if n, err := b.Decode(code); err == nil && n == 42 {
+		t.Errorf("code %q of salt one decodes with salt two", code)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	codec, err := New(testAlphabet, "salt", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := codec.Encode(7)
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"empty", ""},
+		{"one character", "a"},
+		{"outside alphabet", code[:3] + "-" + code[4:]},
+		{"below min length offset", "ab"},
+		{"not produced by the codec", code[:1] + string(code[2]) + string(code[1]) + code[3:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n, err := codec.Decode(tt.code); !errors.Is(err, ErrInvalidCode) {
+				t.Errorf("Decode(%q) = %d, %v, want ErrInvalidCode", tt.code, n, err)
+			}
+		})
+	}
+}
+
+func TestNewRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		alphabet  string
+		minLength int
+	}{
+		{"short alphabet", "abcdef", 0},
+		{"repeated character", "abcdefghijklmnopa", 0},
+		{"min length too large", testAlphabet, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.alphabet, "salt", tt.minLength); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
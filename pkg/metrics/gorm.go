@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startTimeKey = "metrics:start_time"
+
+// InstrumentGorm times every statement issued through database and records
+// it in DbQueryDuration, labelled by the kind of operation and the table.
+func InstrumentGorm(database *gorm.DB) error {
+	callback := database.Callback()
+
+	return errors.Join(
+		callback.Create().Before("*").Register("metrics:before_create", startTimer),
+		callback.Create().After("*").Register("metrics:after_create", observeQuery("create")),
+		callback.Query().Before("*").Register("metrics:before_query", startTimer),
+		callback.Query().After("*").Register("metrics:after_query", observeQuery("query")),
+		callback.Update().Before("*").Register("metrics:before_update", startTimer),
+		callback.Update().After("*").Register("metrics:after_update", observeQuery("update")),
+		callback.Delete().Before("*").Register("metrics:before_delete", startTimer),
+		callback.Delete().After("*").Register("metrics:after_delete", observeQuery("delete")),
+		callback.Row().Before("*").Register("metrics:before_row", startTimer),
+		callback.Row().After("*").Register("metrics:after_row", observeQuery("row")),
+		callback.Raw().Before("*").Register("metrics:before_raw", startTimer),
+		callback.Raw().After("*").Register("metrics:after_raw", observeQuery("raw")),
+	)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func observeQuery(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		DbQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const NAMESPACE = "url_shortener"
+
+const (
+	REDIRECT_HIT     = "hit"
+	REDIRECT_MISS    = "miss"
+	REDIRECT_EXPIRED = "expired"
+	REDIRECT_LOCKED  = "locked"
+
+	JOB_SUCCESS = "success"
+	JOB_FAILURE = "failure"
+
+	UNMATCHED_ROUTE = "unmatched"
+)
+
+// Registry holds every collector of the service, it is served by Handler.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HttpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	Redirects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "redirects_total",
+		Help:      "Short link lookups by result: hit, miss (unknown hash) or expired.",
+	}, []string{"result"})
+
+	DbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	HashCollisions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "hash_collisions_total",
+		Help:      "Generated hashes rejected by the unique index and drawn again.",
+	})
+
+	HashLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Name:      "hash_length",
+		Help:      "Length of newly generated hashes.",
+	})
+
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "job_duration_seconds",
+		Help:      "Background job run time by job and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"job", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HttpRequests,
+		HttpRequestDuration,
+		Redirects,
+		DbQueryDuration,
+		HashCollisions,
+		HashLength,
+		JobDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveJob records a job run that started at start and ended with err.
+func ObserveJob(job string, start time.Time, err error) {
+	outcome := JOB_SUCCESS
+	if err != nil {
+		outcome = JOB_FAILURE
+	}
+	JobDuration.WithLabelValues(job, outcome).Observe(time.Since(start).Seconds())
+}
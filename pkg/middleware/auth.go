@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"UrlShortenerBackend/pkg/problem"
+)
+
+type contextKey string
+
+const ContextUserIdKey contextKey = "user_id"
+
+var ErrInvalidApiKey = errors.New("invalid api key")
+
+// Authenticator resolves an API key to a user ID. It returns
+// ErrInvalidApiKey for unknown or revoked keys.
+type Authenticator func(key string) (string, error)
+
+// Auth identifies the caller from the X-API-Key header or an
+// "Authorization: Bearer" header and puts the user ID on the request context.
+// Requests without a key pass through anonymously, so handlers decide
+// whether they need an identity. A key that cannot be authenticated is only
+// refused under the protected path prefixes, elsewhere (public redirects)
+// the request goes on anonymously.
+func Auth(authenticate Authenticator, protected ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := apiKeyFromRequest(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userId, err := authenticate(key)
+			if err != nil && !hasPrefix(r.URL.Path, protected) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				if errors.Is(err, ErrInvalidApiKey) {
+					problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_INVALID_API_KEY, "The API key is unknown or has been revoked")
+					return
+				}
+				problem.Respond(w, r, http.StatusInternalServerError, problem.CODE_INTERNAL, "Failed to authenticate request")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ContextUserIdKey, userId)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func UserIdFromContext(ctx context.Context) (string, bool) {
+	userId, ok := ctx.Value(ContextUserIdKey).(string)
+	return userId, ok && userId != ""
+}
+
+func hasPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
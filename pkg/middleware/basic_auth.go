@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"UrlShortenerBackend/pkg/problem"
+)
+
+// BasicAuth protects a handler with a single operator account.
+func BasicAuth(user, password string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestUser, requestPassword, ok := r.BasicAuth()
+
+			userMatches := subtle.ConstantTimeCompare([]byte(requestUser), []byte(user)) == 1
+			passwordMatches := subtle.ConstantTimeCompare([]byte(requestPassword), []byte(password)) == 1
+
+			if !ok || !userMatches || !passwordMatches {
+				w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+				problem.Respond(w, r, http.StatusUnauthorized, problem.CODE_UNAUTHORIZED, "Invalid admin credentials")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"UrlShortenerBackend/pkg/problem"
+
 	"github.com/rs/zerolog"
 )
 
@@ -17,6 +19,12 @@ func (w *WrapperWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap gives http.ResponseController access to the underlying writer, so
+// streaming handlers can flush and extend deadlines through the wrapper.
+func (w *WrapperWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func Logging(logger *zerolog.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -29,6 +37,7 @@ func Logging(logger *zerolog.Logger) Middleware {
 
 			logger.Info().
 				Int("status", wrapper.StatusCode).
+				Str("request_id", w.Header().Get(problem.REQUEST_ID_HEADER)).
 				Str("method", r.Method).
 				Str("path", r.URL.Path).
 				Dur("duration", time.Since(start)).
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"UrlShortenerBackend/pkg/metrics"
+)
+
+const routeKey contextKey = "route"
+
+// Metrics counts and times requests per route pattern. It is the first
+// middleware in the chain so requests refused by later middlewares are
+// counted too, and Route, the last one, reports the pattern back to it.
+func Metrics() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapper := &WrapperWriter{
+				ResponseWriter: w,
+				StatusCode:     http.StatusOK,
+			}
+			route := new(string)
+			next.ServeHTTP(wrapper, r.WithContext(context.WithValue(r.Context(), routeKey, route)))
+
+			if *route == "" {
+				*route = metrics.UNMATCHED_ROUTE
+			}
+			status := strconv.Itoa(wrapper.StatusCode)
+
+			metrics.HttpRequests.WithLabelValues(r.Method, *route, status).Inc()
+			metrics.HttpRequestDuration.WithLabelValues(r.Method, *route, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// Route hands the pattern matched by the router to Metrics. The ServeMux
+// sets it on the request it receives, which the middlewares in between may
+// have replaced with a copy, so Route has to sit right in front of the
+// router.
+func Route() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			if route, ok := r.Context().Value(routeKey).(*string); ok {
+				*route = r.Pattern
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+
+	"UrlShortenerBackend/pkg/problem"
+
+	"github.com/google/uuid"
+)
+
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestId echoes a well-formed X-Request-ID from the client or generates
+// one, and sets it on the response before any other middleware runs so
+// logs and problem bodies can refer to it.
+func RequestId() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestId := r.Header.Get(problem.REQUEST_ID_HEADER)
+			if !requestIdPattern.MatchString(requestId) {
+				requestId = uuid.NewString()
+			}
+
+			w.Header().Set(problem.REQUEST_ID_HEADER, requestId)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
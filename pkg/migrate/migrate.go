@@ -0,0 +1,336 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const SCHEMA_MIGRATIONS_TABLE = "schema_migrations"
+
+var (
+	ErrSchemaBehind   = errors.New("database schema is behind")
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a numbered pair of SQL scripts. Up moves the schema to
+// Version, Down moves it back to the previous version.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes a known migration and when it was applied, AppliedAt is
+// nil for pending migrations.
+type Status struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []Migration
+}
+
+// NewMigrator loads the migrations of the database's driver, which live in a
+// directory named after the GORM dialector ("postgres", "sqlite").
+func NewMigrator(database *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	dir, err := fs.Sub(fsys, database.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations found for the %s driver", database.Dialector.Name())
+	}
+
+	return &Migrator{
+		DB:         database,
+		Migrations: migrations,
+	}, nil
+}
+
+// Load reads NNNN_name.up.sql and NNNN_name.down.sql files and returns the
+// migrations ordered by version. Every version needs both scripts.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: matches[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// WithContext returns a copy of the migrator whose queries use ctx.
+func (m *Migrator) WithContext(ctx context.Context) *Migrator {
+	return &Migrator{
+		DB:         m.DB.WithContext(ctx),
+		Migrations: m.Migrations,
+	}
+}
+
+// Latest returns the version the schema is at once every migration is applied.
+func (m *Migrator) Latest() uint {
+	return m.Migrations[len(m.Migrations)-1].Version
+}
+
+// Version returns the highest applied version, 0 for an empty database.
+func (m *Migrator) Version() (uint, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	var version uint
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Check returns ErrSchemaBehind when migrations are pending, the server
+// refuses to start against such a database.
+func (m *Migrator) Check() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d pending migrations, latest is %d", ErrSchemaBehind, len(pending), m.Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration and returns how many were applied.
+func (m *Migrator) Up() (int, error) {
+	return m.Goto(m.Latest())
+}
+
+// Down rolls back the most recently applied migration. It returns 0 when
+// nothing is applied.
+func (m *Migrator) Down() (int, error) {
+	version, err := m.Version()
+	if err != nil || version == 0 {
+		return 0, err
+	}
+
+	target := uint(0)
+	for _, migration := range m.Migrations {
+		if migration.Version < version {
+			target = migration.Version
+		}
+	}
+	return m.Goto(target)
+}
+
+// Goto applies pending migrations up to and including version and rolls
+// back applied ones above it, newest first. Version 0 rolls back everything.
+func (m *Migrator) Goto(version uint) (int, error) {
+	if version != 0 && m.find(version) == nil {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	if err := m.createTable(); err != nil {
+		return 0, err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	for v := range applied {
+		if v > version && m.find(v) == nil {
+			return 0, fmt.Errorf("%w: %d is applied but has no scripts to roll it back", ErrUnknownVersion, v)
+		}
+	}
+
+	count := 0
+	for i := len(m.Migrations) - 1; i >= 0; i-- {
+		migration := m.Migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		if err := m.rollback(migration); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		if err := m.apply(migration); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			return fmt.Errorf("error applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		return tx.Exec(
+			"INSERT INTO "+SCHEMA_MIGRATIONS_TABLE+" (version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now().UTC(),
+		).Error
+	})
+}
+
+func (m *Migrator) rollback(migration Migration) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return fmt.Errorf("error rolling back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		return tx.Exec("DELETE FROM "+SCHEMA_MIGRATIONS_TABLE+" WHERE version = ?", migration.Version).Error
+	})
+}
+
+// createTable creates the table tracking applied migrations, only the
+// commands changing the schema call it.
+func (m *Migrator) createTable() error {
+	err := m.DB.Exec(
+		"CREATE TABLE IF NOT EXISTS " + SCHEMA_MIGRATIONS_TABLE + " (" +
+			"version BIGINT PRIMARY KEY, " +
+			"name TEXT NOT NULL, " +
+			"applied_at TIMESTAMP NOT NULL)",
+	).Error
+	if err != nil {
+		return fmt.Errorf("error creating %s table: %w", SCHEMA_MIGRATIONS_TABLE, err)
+	}
+	return nil
+}
+
+// applied returns when each applied version was applied. It only reads, a
+// database without the migrations table has nothing applied.
+func (m *Migrator) applied() (map[uint]time.Time, error) {
+	if !m.DB.Migrator().HasTable(SCHEMA_MIGRATIONS_TABLE) {
+		return map[uint]time.Time{}, nil
+	}
+
+	var rows []struct {
+		Version   uint
+		AppliedAt time.Time
+	}
+	err := m.DB.Table(SCHEMA_MIGRATIONS_TABLE).Select("version", "applied_at").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for i := range m.Migrations {
+		if m.Migrations[i].Version == version {
+			return &m.Migrations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package mux wraps http.ServeMux to remember the registered patterns, so
+// other parts of the service can tell which paths are taken by routes.
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type Router struct {
+	*http.ServeMux
+	mutex    sync.RWMutex
+	patterns []string
+}
+
+func NewRouter() *Router {
+	return &Router{ServeMux: http.NewServeMux()}
+}
+
+func (router *Router) Handle(pattern string, handler http.Handler) {
+	router.record(pattern)
+	router.ServeMux.Handle(pattern, handler)
+}
+
+func (router *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	router.record(pattern)
+	router.ServeMux.HandleFunc(pattern, handler)
+}
+
+func (router *Router) Patterns() []string {
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+	return append([]string{}, router.patterns...)
+}
+
+// Segments returns the literal first path segments of the registered
+// patterns, e.g. "api" for "GET /api/v1/links". Wildcards are skipped.
+func (router *Router) Segments() []string {
+	seen := map[string]bool{}
+	segments := []string{}
+	for _, pattern := range router.Patterns() {
+		// Drop the method and host, keep the path
+		if i := strings.IndexByte(pattern, ' '); i >= 0 {
+			pattern = strings.TrimSpace(pattern[i+1:])
+		}
+		if i := strings.IndexByte(pattern, '/'); i >= 0 {
+			pattern = pattern[i+1:]
+		}
+
+		segment, _, _ := strings.Cut(pattern, "/")
+		if segment == "" || strings.HasPrefix(segment, "{") || seen[segment] {
+			continue
+		}
+		seen[segment] = true
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+func (router *Router) record(pattern string) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	router.patterns = append(router.patterns, pattern)
+}
@@ -0,0 +1,118 @@
+// Package problem writes RFC 7807 application/problem+json error bodies.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	CONTENT_TYPE      = "application/problem+json"
+	TYPE_PREFIX       = "urn:url-shortener:problem:"
+	REQUEST_ID_HEADER = "X-Request-ID"
+)
+
+// Machine-readable problem codes. The type URI of a problem is TYPE_PREFIX
+// followed by its code.
+const (
+	CODE_INVALID_BODY      = "invalid_body"
+	CODE_VALIDATION_FAILED = "validation_failed"
+	CODE_INVALID_PARAMETER = "invalid_parameter"
+	CODE_UNAUTHORIZED      = "unauthorized"
+	CODE_INVALID_API_KEY   = "invalid_api_key"
+	CODE_FORBIDDEN         = "forbidden"
+	CODE_NOT_FOUND         = "not_found"
+	CODE_HASH_TAKEN        = "hash_taken"
+	CODE_LINK_EXPIRED      = "link_expired"
+	CODE_BATCH_TOO_LARGE   = "batch_too_large"
+	CODE_BATCH_ABORTED     = "batch_aborted"
+	CODE_RATE_LIMITED      = "rate_limited"
+	CODE_TIMED_OUT         = "timed_out"
+	CODE_INTERNAL          = "internal_error"
+)
+
+var titles = map[string]string{
+	CODE_INVALID_BODY:      "Invalid request body",
+	CODE_VALIDATION_FAILED: "Validation failed",
+	CODE_INVALID_PARAMETER: "Invalid parameter",
+	CODE_UNAUTHORIZED:      "Authentication required",
+	CODE_INVALID_API_KEY:   "Invalid API key",
+	CODE_FORBIDDEN:         "Forbidden",
+	CODE_NOT_FOUND:         "Not found",
+	CODE_HASH_TAKEN:        "Hash already exists",
+	CODE_LINK_EXPIRED:      "Link has expired",
+	CODE_BATCH_TOO_LARGE:   "Batch is too large",
+	CODE_BATCH_ABORTED:     "Batch rolled back",
+	CODE_RATE_LIMITED:      "Too many requests",
+	CODE_TIMED_OUT:         "Timed out",
+	CODE_INTERNAL:          "Internal server error",
+}
+
+// FieldError describes one invalid field of a request body
+// @Description Validation failure of a single field
+type FieldError struct {
+	Field   string `json:"field" example:"url"`
+	Rule    string `json:"rule" example:"url"`
+	Message string `json:"message" example:"url must be a valid URL"`
+}
+
+// Problem is an RFC 7807 error body extended with a code, the request ID
+// and, for validation failures, the invalid fields
+// @Description RFC 7807 problem details
+type Problem struct {
+	Type      string       `json:"type" example:"urn:url-shortener:problem:validation_failed"`
+	Title     string       `json:"title" example:"Validation failed"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty" example:"The request body has 1 invalid field"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/links"`
+	Code      string       `json:"code" example:"validation_failed"`
+	RequestId string       `json:"request_id,omitempty" example:"5f0c3a51-6a8e-4d3c-9a55-0d1d7c3e9b1f"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func New(status int, code, detail string) *Problem {
+	title, ok := titles[code]
+	if !ok {
+		title = http.StatusText(status)
+	}
+
+	return &Problem{
+		Type:   TYPE_PREFIX + code,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) WithErrors(errors ...FieldError) *Problem {
+	p.Errors = append(p.Errors, errors...)
+	return p
+}
+
+// Respond writes a problem built from status, code and detail.
+func Respond(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	Write(w, r, New(status, code, detail))
+}
+
+// Write sends p, filling in the request path and the request ID set by the
+// RequestId middleware.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestId == "" {
+		p.RequestId = w.Header().Get(REQUEST_ID_HEADER)
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", CONTENT_TYPE)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
@@ -0,0 +1,109 @@
+// Package ratelimit counts hits per key in fixed windows.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows up to max hits per key in a window that starts with the
+// first hit. It tracks at most capacity keys and refuses new ones while all
+// of them are in use, so flooding it with keys never resets the count of
+// another key. It is safe for concurrent use.
+type Limiter struct {
+	mutex     sync.Mutex
+	max       int
+	window    time.Duration
+	capacity  int
+	windows   map[string]*window
+	nextSweep time.Time
+}
+
+type window struct {
+	count int
+	start time.Time
+}
+
+// New returns a limiter of max hits per window. A max of 0 or less allows
+// everything.
+func New(max int, length time.Duration, capacity int) *Limiter {
+	return &Limiter{
+		max:      max,
+		window:   length,
+		capacity: capacity,
+		windows:  make(map[string]*window),
+	}
+}
+
+// Take reserves a hit for key before the work it guards is done. It
+// returns 0 when the hit is allowed, otherwise how long to wait before the
+// next one.
+func (l *Limiter) Take(key string) time.Duration {
+	if l.max <= 0 {
+		return 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	current, ok := l.windows[key]
+	if ok && !now.Before(current.start.Add(l.window)) {
+		delete(l.windows, key)
+		ok = false
+	}
+
+	if !ok {
+		if len(l.windows) >= l.capacity && !l.sweep(now) {
+			return max(l.nextSweep.Sub(now), time.Second)
+		}
+		current = &window{start: now}
+		l.windows[key] = current
+	}
+
+	if current.count >= l.max {
+		return max(current.start.Add(l.window).Sub(now), time.Second)
+	}
+	current.count++
+
+	return 0
+}
+
+// Release gives back a hit taken for key, for work that turned out not to
+// count against the limit.
+func (l *Limiter) Release(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if current, ok := l.windows[key]; ok && current.count > 0 {
+		current.count--
+	}
+}
+
+// Reset forgets the hits of key.
+func (l *Limiter) Reset(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.windows, key)
+}
+
+// sweep drops the expired windows and reports whether there is room for a
+// new key. Until the oldest window left expires it does not scan again.
+func (l *Limiter) sweep(now time.Time) bool {
+	if now.Before(l.nextSweep) {
+		return false
+	}
+
+	l.nextSweep = now.Add(l.window)
+	for key, current := range l.windows {
+		end := current.start.Add(l.window)
+		if !now.Before(end) {
+			delete(l.windows, key)
+		} else if end.Before(l.nextSweep) {
+			l.nextSweep = end
+		}
+	}
+
+	return len(l.windows) < l.capacity
+}
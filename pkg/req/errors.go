@@ -0,0 +1,66 @@
+package req
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"UrlShortenerBackend/pkg/problem"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldErrors turns validator failures into one entry per invalid field,
+// keyed by the JSON name of the field.
+func FieldErrors(err error) []problem.FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fieldErrors := make([]problem.FieldError, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		fieldErrors = append(fieldErrors, problem.FieldError{
+			Field:   fieldErr.Field(),
+			Rule:    fieldErr.Tag(),
+			Message: fieldMessage(fieldErr),
+		})
+	}
+
+	return fieldErrors
+}
+
+// ValidationProblem reports invalid fields as a 400 validation_failed problem.
+func ValidationProblem(fieldErrors ...problem.FieldError) *problem.Problem {
+	detail := "The request has 1 invalid field"
+	if len(fieldErrors) != 1 {
+		detail = fmt.Sprintf("The request has %d invalid fields", len(fieldErrors))
+	}
+
+	return problem.New(http.StatusBadRequest, problem.CODE_VALIDATION_FAILED, detail).WithErrors(fieldErrors...)
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	field := fieldErr.Field()
+
+	switch fieldErr.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", field)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters or items", field, fieldErr.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters or items", field, fieldErr.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be greater than or equal to %s", field, fieldErr.Param())
+	case "lte":
+		return fmt.Sprintf("%s must be less than or equal to %s", field, fieldErr.Param())
+	case "excludes":
+		return fmt.Sprintf("%s must not contain %q", field, fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, fieldErr.Param())
+	}
+
+	return fmt.Sprintf("%s failed the %s check", field, fieldErr.Tag())
+}
@@ -1,21 +1,24 @@
 package req
 
 import (
-	"UrlShortenerBackend/pkg/res"
 	"net/http"
+
+	"UrlShortenerBackend/pkg/problem"
 )
 
+// HandleBody decodes and validates the JSON body of req. On failure it has
+// already written a problem response and the caller only has to return.
 func HandleBody[T any](w *http.ResponseWriter, req *http.Request) (*T, error) {
 
 	body, err := Decode[T](req.Body)
 	defer req.Body.Close()
 	if err != nil {
-		res.Json(*w, err, http.StatusBadRequest)
+		problem.Respond(*w, req, http.StatusBadRequest, problem.CODE_INVALID_BODY, err.Error())
 		return nil, err
 	}
 	err = IsValid(body)
 	if err != nil {
-		res.Json(*w, err, http.StatusBadRequest)
+		problem.Write(*w, req, ValidationProblem(FieldErrors(err)...))
 		return nil, err
 	}
 	return &body, nil
@@ -1,11 +1,27 @@
 package req
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 )
 
 func IsValid[T any](payload T) error {
 	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
 	err := validate.Struct(payload)
 	return err
 }
+
+// jsonFieldName makes validation errors refer to fields by their JSON name.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
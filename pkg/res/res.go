@@ -17,5 +17,4 @@ func Json(w http.ResponseWriter, data any, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	w.Write(jsonData)
-	fmt.Println("Sending JSON response:", string(jsonData))
 }
@@ -3,10 +3,12 @@ package swagger
 import (
 	"net/http"
 
+	"UrlShortenerBackend/pkg/mux"
+
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func SetupSwagger(router *http.ServeMux) {
+func SetupSwagger(router *mux.Router) {
 
 	router.HandleFunc("GET /docs/swagger.json", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "docs/swagger.json")